@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/cache"
+	"github.com/bonyuta0204/pr-analyzer/internal/config"
+	"github.com/bonyuta0204/pr-analyzer/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local cache",
+	}
+
+	cacheCmd.AddCommand(newCacheStatusCmd())
+	cacheCmd.AddCommand(newCacheClearCmd())
+	cacheCmd.AddCommand(newCachePruneCmd())
+	cacheCmd.AddCommand(newCacheVacuumCmd())
+	cacheCmd.AddCommand(newCacheMigrateCmd())
+
+	return cacheCmd
+}
+
+// openCache opens the cache configured for the command line.
+func openCache(cmd *cobra.Command) (*config.Config, *cache.Store, error) {
+	cfg, err := loadConfig(cmd, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(cfg.Cache.Location, 0750); err != nil {
+		return nil, nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	store, err := cache.NewStore(cfg.CacheDB())
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening cache: %w", err)
+	}
+	return cfg, store, nil
+}
+
+func newCacheStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "List cached repositories",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheStatus,
+	}
+}
+
+func runCacheStatus(cmd *cobra.Command, args []string) error {
+	cfg, store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	fmt.Printf("Cache %s (%s)\n", cfg.CacheDB(), fileSize(cfg.CacheDB()))
+
+	repos, err := store.Repositories()
+	if err != nil {
+		return fmt.Errorf("listing cached repositories: %w", err)
+	}
+	if len(repos) == 0 {
+		fmt.Println("No cached repositories")
+		return nil
+	}
+
+	for _, repo := range repos {
+		total, _, _, err := store.CountPullRequests(repo)
+		if err != nil {
+			return fmt.Errorf("counting PRs of %s: %w", repo, err)
+		}
+		meta, err := store.GetSyncMetadata(repo)
+		if err != nil {
+			return fmt.Errorf("reading sync metadata of %s: %w", repo, err)
+		}
+		checkpoint, err := store.GetFetchCheckpoint(repo)
+		if err != nil {
+			return fmt.Errorf("reading checkpoint of %s: %w", repo, err)
+		}
+
+		status := "never synced"
+		if meta != nil {
+			status = "synced " + meta.LastSyncAt.Local().Format("2006-01-02 15:04")
+			if cfg.ShouldPruneCache(meta.LastSyncAt) {
+				status += ", stale"
+			}
+		}
+		if checkpoint != nil {
+			status += ", interrupted sync can be resumed"
+		}
+		fmt.Printf("  %-40s %6d PRs  %s\n", repo, total, status)
+	}
+
+	return nil
+}
+
+func newCacheClearCmd() *cobra.Command {
+	clearCmd := &cobra.Command{
+		Use:   "clear [owner/repo]",
+		Short: "Delete the cached data of a repository, or of all with --all",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runCacheClear,
+	}
+
+	clearCmd.Flags().Bool("all", false, "Clear every cached repository")
+
+	return clearCmd
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	if all == (len(args) == 1) {
+		return fmt.Errorf("specify either a repository or --all")
+	}
+
+	_, store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var repo string
+	if len(args) == 1 {
+		repo = args[0]
+	}
+	if err := store.Clear(repo); err != nil {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+
+	if all {
+		fmt.Println("Cleared all cached repositories")
+	} else {
+		fmt.Printf("Cleared %s\n", repo)
+	}
+	return nil
+}
+
+func newCachePruneCmd() *cobra.Command {
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete repositories not synced within cache.max_age_days",
+		Args:  cobra.NoArgs,
+		RunE:  runCachePrune,
+	}
+
+	pruneCmd.Flags().Bool("dry-run", false, "List stale repositories without deleting them")
+
+	return pruneCmd
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	repos, err := store.Repositories()
+	if err != nil {
+		return fmt.Errorf("listing cached repositories: %w", err)
+	}
+
+	pruned := 0
+	for _, repo := range repos {
+		meta, err := store.GetSyncMetadata(repo)
+		if err != nil {
+			return fmt.Errorf("reading sync metadata of %s: %w", repo, err)
+		}
+		// Repositories without a completed sync may have one in progress
+		if meta == nil || !cfg.ShouldPruneCache(meta.LastSyncAt) {
+			continue
+		}
+
+		fmt.Printf("  %s (last synced %s)\n", repo, meta.LastSyncAt.Local().Format("2006-01-02"))
+		pruned++
+		if dryRun {
+			continue
+		}
+		if err := store.Clear(repo); err != nil {
+			return fmt.Errorf("clearing %s: %w", repo, err)
+		}
+	}
+
+	switch {
+	case pruned == 0:
+		fmt.Printf("No repositories older than %d days\n", cfg.Cache.MaxAgeDays)
+	case dryRun:
+		fmt.Printf("%d stale repositories not deleted (dry run)\n", pruned)
+	default:
+		fmt.Printf("Pruned %d repositories; run pr-analyzer cache vacuum to reclaim disk space\n", pruned)
+	}
+	return nil
+}
+
+func newCacheVacuumCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "vacuum",
+		Short: "Compact the cache database file",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheVacuum,
+	}
+}
+
+func runCacheVacuum(cmd *cobra.Command, args []string) error {
+	cfg, store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	before := fileSize(cfg.CacheDB())
+	if err := store.Vacuum(); err != nil {
+		return fmt.Errorf("vacuuming cache: %w", err)
+	}
+
+	fmt.Printf("Compacted %s: %s → %s\n", cfg.CacheDB(), before, fileSize(cfg.CacheDB()))
+	return nil
+}
+
+// fileSize formats the size of the file at path for display.
+func fileSize(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "unknown size"
+	}
+	return ui.FormatFileSize(info.Size())
+}
+
+func newCacheMigrateCmd() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending cache schema migrations",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheMigrate,
+	}
+
+	migrateCmd.Flags().Bool("dry-run", false, "Print pending migrations without applying them")
+
+	return migrateCmd
+}
+
+func runCacheMigrate(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, err := loadConfig(cmd, "")
+	if err != nil {
+		return err
+	}
+	plan, err := cache.PlanMigrations(cfg.CacheDB())
+	if err != nil {
+		return fmt.Errorf("inspecting cache: %w", err)
+	}
+
+	fmt.Printf("Cache %s is at schema version %d (latest: %d)\n", cfg.CacheDB(), plan.Current, plan.Latest)
+	if len(plan.Pending) == 0 {
+		fmt.Println("No pending migrations")
+		return nil
+	}
+
+	for _, m := range plan.Pending {
+		fmt.Printf("  %04d %s\n", m.Version, m.Name)
+	}
+
+	if dryRun {
+		fmt.Printf("%d pending migration(s) not applied (dry run)\n", len(plan.Pending))
+		return nil
+	}
+
+	if err := os.MkdirAll(cfg.Cache.Location, 0750); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	store, err := cache.NewStore(cfg.CacheDB())
+	if err != nil {
+		return fmt.Errorf("migrating cache: %w", err)
+	}
+	defer store.Close()
+
+	fmt.Printf("Applied %d migration(s)\n", len(plan.Pending))
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configPath returns the config file selected by --config.
+func configPath(cmd *cobra.Command) string {
+	if path, _ := cmd.Flags().GetString("config"); path != "" {
+		return path
+	}
+	return config.DefaultPath()
+}
+
+// configProfile returns the profile selected by --profile.
+func configProfile(cmd *cobra.Command) string {
+	if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+		return profile
+	}
+	return os.Getenv("PR_ANALYZER_PROFILE")
+}
+
+// loadConfig loads the configuration for repo, which may be empty, with
+// the config file and profile selected on the command line.
+func loadConfig(cmd *cobra.Command, repo string) (*config.Config, error) {
+	cfg, err := config.Load(configPath(cmd), config.LoadOptions{Profile: configProfile(cmd), Repo: repo})
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg, nil
+}
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration file",
+		Long: `Settings are resolved in this order, later ones taking precedence:
+
+  1. Built-in defaults
+  2. The config file
+  3. The profile selected with --profile
+  4. Overrides for the repository, under repos (a profile's own repos come last)
+  5. Environment variables: GITHUB_TOKEN, GITHUB_API_URL, ... and PR_ANALYZER_<SETTING>,
+     e.g. PR_ANALYZER_FETCH_CONCURRENCY for fetch.concurrency
+  6. Command-line flags`,
+	}
+
+	configCmd.AddCommand(newConfigShowCmd())
+	configCmd.AddCommand(newConfigSetCmd())
+	configCmd.AddCommand(newConfigInitCmd())
+
+	return configCmd
+}
+
+func newConfigShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [owner/repo]",
+		Short: "Print the effective configuration, optionally for a repository",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runConfigShow,
+	}
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	var repo string
+	if len(args) > 0 {
+		repo = args[0]
+	}
+
+	cfg, err := loadConfig(cmd, repo)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+
+	fmt.Printf("# Config file: %s\n", configPath(cmd))
+	if profile := configProfile(cmd); profile != "" {
+		fmt.Printf("# Profile: %s\n", profile)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func newConfigSetCmd() *cobra.Command {
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a value in the configuration file",
+		Long: `Set a value in the configuration file, in the profile selected with --profile
+and for the repository selected with --repo, if any.
+
+Keys:
+  ` + strings.Join(config.Keys(), "\n  "),
+		Args: cobra.ExactArgs(2),
+		RunE: runConfigSet,
+	}
+
+	setCmd.Flags().String("repo", "", "Set the value for this owner/repo only")
+
+	return setCmd
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	repo, _ := cmd.Flags().GetString("repo")
+	path := configPath(cmd)
+
+	opts := config.LoadOptions{Profile: configProfile(cmd), Repo: repo}
+	if err := config.SetInFile(path, opts, args[0], args[1]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Set %s in %s\n", args[0], path)
+	return nil
+}
+
+func newConfigInitCmd() *cobra.Command {
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a configuration file with the default settings",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigInit,
+	}
+
+	initCmd.Flags().Bool("force", false, "Overwrite an existing configuration file")
+
+	return initCmd
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	path := configPath(cmd)
+
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("config file %s already exists (use --force to overwrite)", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("checking config file: %w", err)
+	}
+
+	if err := config.DefaultConfig().Save(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
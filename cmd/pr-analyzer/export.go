@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newExportCmd() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export <owner/repo>",
+		Short: "Export cached PRs without contacting GitHub",
+		Long: `Export pull requests from the local cache only. No GitHub token is needed,
+so previously fetched data can be re-exported offline.
+
+Examples:
+  pr-analyzer export microsoft/vscode --format csv
+  pr-analyzer export microsoft/vscode --since 2024-01-01 --all
+  pr-analyzer export microsoft/vscode --all --merged-only --base main --path 'src/vs/editor/**'
+  pr-analyzer export microsoft/vscode --all --query 'is:merged label:bug -label:wip created:>2025-01-01'`,
+		Args: cobra.ExactArgs(1),
+		RunE: runExport,
+	}
+
+	addSelectFlags(exportCmd)
+	addExportFlags(exportCmd)
+	addFilterFlags(exportCmd)
+
+	return exportCmd
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	opts := analyzeOptions(cmd, args[0])
+	opts.Offline = true
+
+	service, err := newService(cmd, opts.Repo)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	return service.Analyze(cmd.Context(), opts)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/bonyuta0204/pr-analyzer/internal/analyzer"
+	"github.com/spf13/cobra"
+)
+
+// addSelectFlags registers the flags that select which PRs are fetched or
+// exported.
+func addSelectFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("limit", 100, "Recent N PRs (use --all for unlimited)")
+	cmd.Flags().Bool("all", false, "All PRs (overrides --limit)")
+	cmd.Flags().String("since", "", "Only PRs updated since date (YYYY-MM-DD)")
+	cmd.Flags().Int("pr", 0, "Specific PR number only")
+}
+
+// addFetchFlags registers the flags that control fetching from GitHub.
+func addFetchFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("refetch", false, "Force refetch all data (ignore cache)")
+	cmd.Flags().Bool("resume", false, "Resume the previous interrupted sync for this repository")
+	cmd.Flags().Int("concurrency", 0, "Number of PRs to fetch details for in parallel (default from config)")
+	cmd.Flags().String("api", "", "GitHub API used to fetch PRs: rest, graphql (default from config)")
+}
+
+// addExportFlags registers the flags that control the export file.
+func addExportFlags(cmd *cobra.Command) {
+	cmd.Flags().String("format", "jsonl", "Export format: jsonl, csv")
+	cmd.Flags().Bool("include-diffs", false, "Include file diffs in export")
+	cmd.Flags().String("output", "", "Custom output filename")
+}
+
+// addFilterFlags registers the flags that narrow down the exported PRs.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("state", "", "Only PRs in this state: open, closed, merged, all")
+	cmd.Flags().StringSlice("author", nil, "Only PRs opened by these users (repeatable)")
+	cmd.Flags().StringSlice("reviewer", nil, "Only PRs reviewed by these users (repeatable)")
+	cmd.Flags().StringSlice("label", nil, "Only PRs with any of these labels (repeatable)")
+	cmd.Flags().StringSlice("path", nil, "Only PRs changing files matching these globs, e.g. 'cmd/*' (repeatable)")
+	cmd.Flags().String("base", "", "Only PRs targeting this base branch")
+	cmd.Flags().Bool("exclude-bots", false, "Leave out PRs opened by bots")
+	cmd.Flags().Bool("merged-only", false, "Only merged PRs")
+	cmd.Flags().String("created-since", "", "Only PRs created on or after date (YYYY-MM-DD)")
+	cmd.Flags().String("created-until", "", "Only PRs created on or before date (YYYY-MM-DD)")
+	cmd.Flags().String("query", "", "GitHub search query, e.g. 'is:merged label:bug -author:app/dependabot'")
+}
+
+// analyzeOptions reads the flags registered on cmd by the functions above.
+// Flags cmd does not have keep their zero value.
+func analyzeOptions(cmd *cobra.Command, repo string) analyzer.AnalyzeOptions {
+	opts := analyzer.AnalyzeOptions{Repo: repo}
+
+	opts.Limit, _ = cmd.Flags().GetInt("limit")
+	opts.All, _ = cmd.Flags().GetBool("all")
+	opts.Since, _ = cmd.Flags().GetString("since")
+	opts.PRNumber, _ = cmd.Flags().GetInt("pr")
+
+	opts.Refetch, _ = cmd.Flags().GetBool("refetch")
+	opts.Resume, _ = cmd.Flags().GetBool("resume")
+	opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+	opts.API, _ = cmd.Flags().GetString("api")
+
+	opts.Format, _ = cmd.Flags().GetString("format")
+	opts.IncludeDiffs, _ = cmd.Flags().GetBool("include-diffs")
+	opts.Output, _ = cmd.Flags().GetString("output")
+
+	opts.State, _ = cmd.Flags().GetString("state")
+	opts.Authors, _ = cmd.Flags().GetStringSlice("author")
+	opts.Reviewers, _ = cmd.Flags().GetStringSlice("reviewer")
+	opts.Labels, _ = cmd.Flags().GetStringSlice("label")
+	opts.Paths, _ = cmd.Flags().GetStringSlice("path")
+	opts.Base, _ = cmd.Flags().GetString("base")
+	opts.ExcludeBots, _ = cmd.Flags().GetBool("exclude-bots")
+	opts.MergedOnly, _ = cmd.Flags().GetBool("merged-only")
+	opts.CreatedSince, _ = cmd.Flags().GetString("created-since")
+	opts.CreatedUntil, _ = cmd.Flags().GetString("created-until")
+	opts.Query, _ = cmd.Flags().GetString("query")
+
+	return opts
+}
@@ -2,14 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/bonyuta0204/pr-analyzer/internal/analyzer"
+	"github.com/bonyuta0204/pr-analyzer/internal/cache"
+	"github.com/bonyuta0204/pr-analyzer/internal/github"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes, so that wrapper scripts can tell failures worth retrying
+// (rate limits, network and server errors) from those that need a fix.
+const (
+	exitError       = 1
+	exitAuth        = 3
+	exitSSO         = 4
+	exitForbidden   = 5
+	exitNotFound    = 6
+	exitRateLimited = 7
+	exitNetwork     = 8
+	exitCache       = 9
+	exitInterrupted = 130
+)
+
 var (
 	version = "dev"
 	commit  = "none"
@@ -20,9 +39,68 @@ func main() {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
-	if err := newRootCmd().Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	// Cancel in-flight work on Ctrl-C so that fetch progress is checkpointed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cmd, err := newRootCmd().ExecuteContextC(ctx); err != nil {
+		// Only commands that sync checkpoint their progress for --resume
+		offline, _ := cmd.Flags().GetBool("offline")
+		code, hint := exitStatus(err, cmd.Flags().Lookup("resume") != nil && !offline)
+		switch {
+		case code == exitInterrupted && hint != "":
+			fmt.Fprintf(os.Stderr, "Interrupted: %s\n", hint)
+		case code == exitInterrupted:
+			fmt.Fprintln(os.Stderr, "Interrupted")
+		default:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if hint != "" {
+				fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+			}
+		}
+		stop()
+		os.Exit(code)
+	}
+}
+
+// exitStatus maps err to the process exit code and a hint on how to
+// recover. resumable reports whether the failed command saved its progress
+// for --resume.
+func exitStatus(err error, resumable bool) (int, string) {
+	var apiErr *github.APIError
+	switch {
+	case errors.Is(err, context.Canceled) && resumable:
+		return exitInterrupted, "progress was saved, rerun with --resume to continue"
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted, ""
+	case errors.Is(err, github.ErrUnauthorized):
+		return exitAuth, "check that GITHUB_TOKEN is set to a valid, unexpired token"
+	case errors.Is(err, github.ErrSSORequired):
+		hint := "authorize the token for the organization's SAML SSO in your GitHub token settings"
+		if errors.As(err, &apiErr) && apiErr.SSOURL != "" {
+			hint = "authorize the token for SAML SSO at " + apiErr.SSOURL
+		}
+		return exitSSO, hint
+	case errors.Is(err, github.ErrForbidden):
+		return exitForbidden, "check that the token has the repo scope and can read the repository"
+	case errors.Is(err, github.ErrNotFound):
+		return exitNotFound, "check the repository name; private repositories also report not found when the token cannot access them"
+	case errors.Is(err, github.ErrRateLimited) && resumable:
+		return exitRateLimited, "retry after the rate limit resets; progress was saved for --resume"
+	case errors.Is(err, github.ErrRateLimited):
+		return exitRateLimited, "retry after the rate limit resets"
+	case (errors.Is(err, github.ErrNetwork) || errors.Is(err, github.ErrServer)) && resumable:
+		return exitNetwork, "this is usually transient; retry later with --resume"
+	case errors.Is(err, github.ErrNetwork), errors.Is(err, github.ErrServer):
+		return exitNetwork, "this is usually transient; retry later"
+	case errors.Is(err, analyzer.ErrNotCached):
+		return exitNotFound, "fetch it first with pr-analyzer sync <owner/repo>"
+	case errors.Is(err, cache.ErrSchemaTooNew):
+		return exitCache, "the cache was written by a newer pr-analyzer; upgrade or point cache.location elsewhere"
+	case errors.Is(err, cache.ErrIO):
+		return exitCache, "check that the cache directory is writable and not used by another pr-analyzer process"
+	default:
+		return exitError, ""
 	}
 }
 
@@ -32,69 +110,73 @@ func newRootCmd() *cobra.Command {
 		Short: "Analyze GitHub PR data and export for analysis",
 		Long: `pr-analyzer fetches GitHub pull request data, caches it locally, and exports it in analysis-ready formats.
 
+Running pr-analyzer <owner/repo> syncs and then exports in one step, the same as
+pr-analyzer sync followed by pr-analyzer export.
+
 Examples:
   pr-analyzer microsoft/vscode                    # Export recent 100 PRs to JSONL
   pr-analyzer microsoft/vscode --limit 50        # Export recent 50 PRs  
   pr-analyzer microsoft/vscode --format csv      # Export to CSV format
   pr-analyzer microsoft/vscode --all             # Export all PRs
-  pr-analyzer microsoft/vscode --refetch         # Force refresh cache`,
+  pr-analyzer microsoft/vscode --refetch         # Force refresh cache
+  pr-analyzer microsoft/vscode --resume          # Continue an interrupted sync
+  pr-analyzer microsoft/vscode --offline         # Export from the cache only`,
 		Args: cobra.ExactArgs(1),
 		RunE: runAnalyze,
+		// main reports errors with a hint and a distinct exit code
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
 	// Add flags
-	rootCmd.Flags().String("format", "jsonl", "Export format: jsonl, csv")
-	rootCmd.Flags().Int("limit", 100, "Fetch recent N PRs (use --all for unlimited)")
-	rootCmd.Flags().Bool("all", false, "Fetch all PRs (overrides --limit)")
-	rootCmd.Flags().Bool("include-diffs", false, "Include file diffs in export")
-	rootCmd.Flags().Bool("refetch", false, "Force refetch all data (ignore cache)")
-	rootCmd.Flags().String("since", "", "Fetch PRs updated since date (YYYY-MM-DD)")
-	rootCmd.Flags().Int("pr", 0, "Fetch specific PR number only")
-	rootCmd.Flags().String("output", "", "Custom output filename")
-
-	// Add version as subcommand
+	addSelectFlags(rootCmd)
+	addFetchFlags(rootCmd)
+	addExportFlags(rootCmd)
+	addFilterFlags(rootCmd)
+	rootCmd.Flags().Bool("offline", false, "Export from the cache only, without contacting GitHub")
+	rootCmd.PersistentFlags().String("config", "", "Config file (default $PR_ANALYZER_CONFIG or ~/.pr-analyzer/config.yaml)")
+	rootCmd.PersistentFlags().String("profile", "", "Config profile to use (default $PR_ANALYZER_PROFILE)")
+
+	// Add subcommands
 	rootCmd.AddCommand(newVersionCmd())
+	rootCmd.AddCommand(newCacheCmd())
+	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newSyncCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newStatsCmd())
 
 	return rootCmd
 }
 
+// runAnalyze syncs and then exports in one go. It predates the sync and
+// export subcommands and is kept as a shortcut for them.
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	// Parse repository argument
-	repo := args[0]
-
-	// Get flags
-	format, _ := cmd.Flags().GetString("format")
-	limit, _ := cmd.Flags().GetInt("limit")
-	all, _ := cmd.Flags().GetBool("all")
-	includeDiffs, _ := cmd.Flags().GetBool("include-diffs")
-	refetch, _ := cmd.Flags().GetBool("refetch")
-	since, _ := cmd.Flags().GetString("since")
-	prNumber, _ := cmd.Flags().GetInt("pr")
-	output, _ := cmd.Flags().GetString("output")
-
-	// Create analyzer service
-	service, err := analyzer.NewService()
+	opts := analyzeOptions(cmd, args[0])
+	opts.Offline, _ = cmd.Flags().GetBool("offline")
+
+	service, err := newService(cmd, opts.Repo)
 	if err != nil {
-		return fmt.Errorf("initializing analyzer: %w", err)
+		return err
 	}
 	defer service.Close()
 
-	// Create analyze options
-	opts := analyzer.AnalyzeOptions{
-		Repo:         repo,
-		Format:       format,
-		Limit:        limit,
-		All:          all,
-		IncludeDiffs: includeDiffs,
-		Refetch:      refetch,
-		Since:        since,
-		PRNumber:     prNumber,
-		Output:       output,
+	// Run analysis
+	return service.Analyze(cmd.Context(), opts)
+}
+
+// newService creates the analyzer service with the configuration for repo,
+// which may be empty.
+func newService(cmd *cobra.Command, repo string) (*analyzer.Service, error) {
+	cfg, err := loadConfig(cmd, repo)
+	if err != nil {
+		return nil, err
 	}
 
-	// Run analysis
-	ctx := context.Background()
-	return service.Analyze(ctx, opts)
+	service, err := analyzer.NewService(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("initializing analyzer: %w", err)
+	}
+	return service, nil
 }
 
 func newVersionCmd() *cobra.Command {
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newStatsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats <owner/repo>",
+		Short: "Show what the cache holds for a repository",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runStats,
+	}
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	service, err := newService(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	return service.Stats(args[0])
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd() *cobra.Command {
+	syncCmd := &cobra.Command{
+		Use:   "sync <owner/repo>",
+		Short: "Fetch new and updated PRs into the cache without exporting",
+		Long: `Fetch new and updated pull requests from GitHub into the local cache.
+Use pr-analyzer export to write them to a file afterwards.
+
+Examples:
+  pr-analyzer sync microsoft/vscode --all
+  pr-analyzer sync microsoft/vscode --resume`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSync,
+	}
+
+	addSelectFlags(syncCmd)
+	addFetchFlags(syncCmd)
+	// --api graphql leaves out file diffs unless they are asked for
+	syncCmd.Flags().Bool("include-diffs", false, "Fetch file diffs for a later export --include-diffs")
+
+	return syncCmd
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	opts := analyzeOptions(cmd, args[0])
+
+	service, err := newService(cmd, opts.Repo)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+
+	return service.Sync(cmd.Context(), opts)
+}
@@ -2,7 +2,9 @@ package analyzer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"time"
 
@@ -14,6 +16,14 @@ import (
 	"github.com/bonyuta0204/pr-analyzer/pkg/models"
 )
 
+// ErrNotCached is returned by an offline analysis of a repository or PR
+// that has never been fetched.
+var ErrNotCached = errors.New("not in the cache")
+
+// ErrMissingPatches is returned by an export with diffs of a PR whose files
+// were cached from GraphQL, without their patches.
+var ErrMissingPatches = errors.New("cached without file diffs")
+
 type Service struct {
 	config   *config.Config
 	cache    *cache.Store
@@ -31,17 +41,82 @@ type AnalyzeOptions struct {
 	Since        string
 	PRNumber     int
 	Output       string
+	Concurrency  int
+	Resume       bool
+	API          string
+	// Offline exports from the cache without contacting GitHub
+	Offline bool
+
+	// Filters applied to the cached PRs when exporting; list fields match
+	// PRs with any of their values
+	State        string
+	Authors      []string
+	Reviewers    []string
+	Labels       []string
+	Paths        []string
+	Base         string
+	ExcludeBots  bool
+	MergedOnly   bool
+	CreatedSince string
+	CreatedUntil string
+	// Query is a GitHub search query such as "is:merged label:bug"
+	Query string
 }
 
-func NewService() (*Service, error) {
-	// Load configuration
-	cfg := config.DefaultConfig()
+// exportFilter converts the selection and filters in opts into a cache query.
+func exportFilter(opts AnalyzeOptions) (cache.Filter, error) {
+	filter := cache.Filter{
+		Number:      opts.PRNumber,
+		MergedOnly:  opts.MergedOnly,
+		Authors:     opts.Authors,
+		Reviewers:   opts.Reviewers,
+		Labels:      opts.Labels,
+		Paths:       opts.Paths,
+		Base:        opts.Base,
+		ExcludeBots: opts.ExcludeBots,
+	}
 
-	// Validate GitHub token
-	if cfg.GitHub.Token == "" {
-		return nil, fmt.Errorf("GITHUB_TOKEN environment variable is required")
+	switch opts.State {
+	case "", "all":
+	case "open", "closed":
+		filter.State = opts.State
+	case "merged":
+		filter.MergedOnly = true
+	default:
+		return cache.Filter{}, fmt.Errorf("invalid state %q: use open, closed, merged or all", opts.State)
+	}
+
+	var err error
+	if filter.UpdatedSince, err = parseSince(opts.Since); err != nil {
+		return cache.Filter{}, err
+	}
+	if filter.CreatedSince, err = parseSince(opts.CreatedSince); err != nil {
+		return cache.Filter{}, err
+	}
+	until, err := parseSince(opts.CreatedUntil)
+	if err != nil {
+		return cache.Filter{}, err
+	}
+	if !until.IsZero() {
+		// Include PRs created on the until date itself
+		filter.CreatedUntil = until.AddDate(0, 0, 1)
+	}
+
+	if opts.Query != "" {
+		if filter.Query, err = cache.ParseQuery(opts.Query); err != nil {
+			return cache.Filter{}, err
+		}
+	}
+
+	if !opts.All && opts.Limit > 0 {
+		filter.Limit = opts.Limit
 	}
+	return filter, nil
+}
 
+// NewService opens the cache configured in cfg. Settings given as flags
+// in AnalyzeOptions are applied on top of cfg by Analyze.
+func NewService(cfg *config.Config) (*Service, error) {
 	// Create cache directory if it doesn't exist
 	if err := ensureCacheDir(cfg.Cache.Location); err != nil {
 		return nil, fmt.Errorf("creating cache directory: %w", err)
@@ -61,16 +136,23 @@ func NewService() (*Service, error) {
 }
 
 func (s *Service) Analyze(ctx context.Context, opts AnalyzeOptions) error {
+	if opts.Offline && (opts.Refetch || opts.Resume) {
+		return fmt.Errorf("--offline cannot be combined with --refetch or --resume")
+	}
+	// Report a malformed filter or query before spending time on a fetch
+	if _, err := exportFilter(opts); err != nil {
+		return err
+	}
+
 	// Start analysis display
 	s.progress.StartSection("🔍", fmt.Sprintf("Analyzing %s", opts.Repo))
 
-	// Initialize GitHub client for this repo
-	githubClient, err := github.NewClient(s.config, s.cache, opts.Repo)
-	if err != nil {
-		s.progress.ShowError(fmt.Errorf("initializing GitHub client: %w", err))
-		return err
+	if !opts.Offline {
+		if err := s.connect(opts); err != nil {
+			s.progress.ShowError(err)
+			return err
+		}
 	}
-	s.github = githubClient
 
 	// Check cache status
 	if cacheErr := s.showCacheStatus(opts.Repo, opts.Refetch); cacheErr != nil {
@@ -78,8 +160,14 @@ func (s *Service) Analyze(ctx context.Context, opts AnalyzeOptions) error {
 		return cacheErr
 	}
 
-	// Fetch data from GitHub
-	if fetchErr := s.fetchData(ctx, opts); fetchErr != nil {
+	// Fetch data from GitHub, unless exporting what is already cached
+	var fetchErr error
+	if opts.Offline {
+		fetchErr = s.checkCached(opts.Repo)
+	} else {
+		fetchErr = s.fetchData(ctx, opts)
+	}
+	if fetchErr != nil {
 		s.progress.ShowError(fetchErr)
 		return fetchErr
 	}
@@ -92,18 +180,125 @@ func (s *Service) Analyze(ctx context.Context, opts AnalyzeOptions) error {
 	}
 
 	// Export data
-	filename, fileSize, err := s.exportData(prs, opts)
+	filename, count, fileSize, err := s.exportData(prs, opts)
 	if err != nil {
 		s.progress.ShowError(err)
 		return err
 	}
 
 	// Show success
-	s.progress.ShowSuccess(len(prs), filename, ui.FormatFileSize(fileSize))
+	s.progress.ShowSuccess(count, filename, ui.FormatFileSize(fileSize))
 
 	return nil
 }
 
+// Sync fetches new and updated PRs of opts.Repo into the cache without
+// exporting them.
+func (s *Service) Sync(ctx context.Context, opts AnalyzeOptions) error {
+	s.progress.StartSection("🔄", fmt.Sprintf("Syncing %s", opts.Repo))
+
+	if err := s.connect(opts); err != nil {
+		s.progress.ShowError(err)
+		return err
+	}
+
+	if err := s.showCacheStatus(opts.Repo, opts.Refetch); err != nil {
+		s.progress.ShowError(err)
+		return err
+	}
+
+	if err := s.fetchData(ctx, opts); err != nil {
+		s.progress.ShowError(err)
+		return err
+	}
+
+	total, _, _, err := s.cache.CountPullRequests(opts.Repo)
+	if err != nil {
+		s.progress.ShowError(err)
+		return err
+	}
+	s.progress.ShowDone(fmt.Sprintf("%d PRs cached for %s", total, opts.Repo))
+
+	return nil
+}
+
+// Stats displays what the cache holds for repo.
+func (s *Service) Stats(repo string) error {
+	s.progress.StartSection("📊", fmt.Sprintf("Cached data for %s", repo))
+
+	if err := s.checkCached(repo); err != nil {
+		s.progress.ShowError(err)
+		return err
+	}
+
+	stats, err := s.cache.GetStats(repo)
+	if err != nil {
+		s.progress.ShowError(err)
+		return err
+	}
+
+	count := func(key string) int {
+		n, _ := stats[key].(int64)
+		return int(n)
+	}
+	s.progress.ShowCount("Pull requests", count("total_prs"), fmt.Sprintf("(%d open, %d closed)", count("open_prs"), count("closed_prs")))
+	s.progress.ShowCount("Reviews", count("total_reviews"), "items")
+	s.progress.ShowCount("Comments", count("total_comments"), "items")
+	s.progress.ShowCount("Files", count("total_files"), "items")
+	s.progress.ShowCount("Review threads", count("total_threads"), "items")
+	s.progress.ShowCount("Commits", count("total_commits"), "items")
+	s.progress.ShowCount("CI checks", count("total_checks"), "items")
+	s.progress.ShowCount("Timeline events", count("total_timeline_events"), "items")
+
+	lastSync := "never completed"
+	if at, ok := stats["last_sync"].(time.Time); ok {
+		lastSync = "last synced " + at.Local().Format("2006-01-02 15:04")
+		if s.config.ShouldPruneCache(at) {
+			lastSync += fmt.Sprintf(", older than %d days", s.config.Cache.MaxAgeDays)
+		}
+	}
+	s.progress.ShowDone(lastSync)
+
+	return nil
+}
+
+// connect creates the GitHub client for opts.Repo.
+func (s *Service) connect(opts AnalyzeOptions) error {
+	// Command-line flags take precedence over configuration
+	if opts.Concurrency > 0 {
+		s.config.Fetch.Concurrency = opts.Concurrency
+	}
+	if opts.API != "" {
+		s.config.Fetch.API = opts.API
+	}
+
+	// Validate GitHub token
+	if len(s.config.GitHub.AllTokens()) == 0 && !s.config.GitHub.App.Enabled() {
+		return fmt.Errorf("%w: GITHUB_TOKEN environment variable is required", github.ErrUnauthorized)
+	}
+
+	// Initialize GitHub client for this repo
+	githubClient, err := github.NewClient(s.config, s.cache, opts.Repo)
+	if err != nil {
+		return fmt.Errorf("initializing GitHub client: %w", err)
+	}
+	githubClient.OnRateLimitPause(s.progress.ShowRateLimitPause)
+	s.github = githubClient
+	return nil
+}
+
+// checkCached fails when nothing has been fetched for repo yet.
+func (s *Service) checkCached(repo string) error {
+	total, _, _, err := s.cache.CountPullRequests(repo)
+	if err != nil {
+		return fmt.Errorf("reading cache: %w", err)
+	}
+	if total == 0 {
+		return fmt.Errorf("%s is %w", repo, ErrNotCached)
+	}
+	return nil
+}
+
 func (s *Service) showCacheStatus(repo string, refetch bool) error {
 	if refetch {
 		s.progress.ShowCacheStatus(0, "forced refresh")
@@ -141,93 +336,109 @@ func (s *Service) fetchData(ctx context.Context, opts AnalyzeOptions) error {
 	s.progress.StartFetching()
 
 	// Parse since date if provided
-	var sinceTime time.Time
-	if opts.Since != "" {
-		var err error
-		sinceTime, err = time.Parse("2006-01-02", opts.Since)
-		if err != nil {
-			return fmt.Errorf("invalid date format '%s': use YYYY-MM-DD", opts.Since)
-		}
+	sinceTime, err := parseSince(opts.Since)
+	if err != nil {
+		return err
 	}
 
 	// Show progress for fetching PRs
 	s.progress.ShowProgress("Recent PRs", 0, "fetching")
 
 	// Fetch from GitHub
-	err := s.github.FetchPullRequests(ctx, sinceTime, opts.PRNumber, opts.Limit)
+	fetchOpts := github.FetchOptions{
+		Since:    sinceTime,
+		PRNumber: opts.PRNumber,
+		Limit:    opts.Limit,
+		All:      opts.All,
+		Refetch:  opts.Refetch,
+		Resume:   opts.Resume,
+		Patches:  opts.IncludeDiffs,
+	}
+	result, err := s.github.FetchPullRequests(ctx, fetchOpts)
 	if err != nil {
 		return fmt.Errorf("fetching pull requests: %w", err)
 	}
 
+	s.progress.UpdateProgress(result.New, "new")
 	s.progress.StopProgress()
 
-	// The actual fetching of reviews, comments, and files happens inside FetchPullRequests
-	// So we just show completed status here
-	s.progress.ShowProgress("Reviews", 0, "fetching")
-	s.progress.StopProgress()
-
-	s.progress.ShowProgress("Comments", 0, "fetching")
-	s.progress.StopProgress()
-
-	s.progress.ShowProgress("Files", 0, "fetching")
-	s.progress.StopProgress()
+	s.progress.ShowCount("Changed PRs", result.Changed, "changed")
+	s.progress.ShowCount("Unchanged PRs", result.Unchanged, "unchanged")
+	s.progress.ShowCount("Reviews", result.Reviews, "items")
+	s.progress.ShowCount("Comments", result.Comments, "items")
+	s.progress.ShowCount("Files", result.Files, "items")
+	s.progress.ShowCount("Review threads", result.Threads, "items")
+	s.progress.ShowCount("Commits", result.Commits, "items")
+	s.progress.ShowCount("CI checks", result.Checks, "items")
+	s.progress.ShowCount("Timeline events", result.TimelineEvents, "items")
+	s.progress.ShowCount("Not modified", result.ETagHits, fmt.Sprintf("of %d pages", result.ETagHits+result.ETagMisses))
+	s.progress.ShowCount("Retries", result.Retries, "requests")
+	if len(result.Tokens) > 1 {
+		for _, token := range result.Tokens {
+			unit := "requests"
+			if token.Remaining >= 0 {
+				unit = fmt.Sprintf("requests, %d left", token.Remaining)
+			}
+			s.progress.ShowCount("Token "+token.Name, token.Requests, unit)
+		}
+	}
 
 	return nil
 }
 
-func (s *Service) loadDataFromCache(opts AnalyzeOptions) ([]*models.PullRequest, error) {
-	// For now, load all PRs from cache
-	// TODO: Apply limit and filtering
-	prs, err := s.cache.GetPullRequests(opts.Repo, time.Time{})
+// loadDataFromCache returns the cached PRs selected by opts. They are read
+// from the cache as the export consumes them.
+func (s *Service) loadDataFromCache(opts AnalyzeOptions) (iter.Seq2[*models.PullRequest, error], error) {
+	filter, err := exportFilter(opts)
 	if err != nil {
-		return nil, fmt.Errorf("loading PRs from cache: %w", err)
+		return nil, err
 	}
 
-	// Apply limit if specified and not fetching all
-	if !opts.All && opts.Limit > 0 && len(prs) > opts.Limit {
-		prs = prs[:opts.Limit]
-	}
-
-	// Load associated data for each PR
-	for _, pr := range prs {
-		// Load reviews
-		reviews, err := s.cache.GetReviews(pr.Number)
+	// Tell a PR that was never fetched from one excluded by the filters
+	if opts.PRNumber > 0 {
+		cached, err := s.cache.QueryPullRequests(opts.Repo, cache.Filter{Number: opts.PRNumber})
 		if err != nil {
-			return nil, fmt.Errorf("loading reviews for PR %d: %w", pr.Number, err)
+			return nil, fmt.Errorf("loading PRs from cache: %w", err)
 		}
-		// Convert slice of pointers to slice of values
-		pr.Reviews = make([]models.Review, len(reviews))
-		for i, review := range reviews {
-			pr.Reviews[i] = *review
+		if len(cached) == 0 {
+			return nil, fmt.Errorf("PR #%d of %s is %w", opts.PRNumber, opts.Repo, ErrNotCached)
 		}
+	}
 
-		// Load comments
-		comments, err := s.cache.GetComments(pr.Number)
-		if err != nil {
-			return nil, fmt.Errorf("loading comments for PR %d: %w", pr.Number, err)
-		}
-		// Convert slice of pointers to slice of values
-		pr.Comments = make([]models.Comment, len(comments))
-		for i, comment := range comments {
-			pr.Comments[i] = *comment
-		}
+	prs := s.cache.PullRequests(opts.Repo, filter)
+	if !opts.IncludeDiffs {
+		return prs, nil
+	}
 
-		// Load files
-		files, err := s.cache.GetFiles(pr.Number)
-		if err != nil {
-			return nil, fmt.Errorf("loading files for PR %d: %w", pr.Number, err)
-		}
-		// Convert slice of pointers to slice of values
-		pr.Files = make([]models.File, len(files))
-		for i, file := range files {
-			pr.Files[i] = *file
-		}
+	// A sync with diffs fetches missing patches, so only PRs it failed to
+	// fetch or an offline export can run into them
+	numbers, err := s.cache.GetPullsMissingPatches(opts.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("loading PRs from cache: %w", err)
+	}
+	if len(numbers) == 0 {
+		return prs, nil
+	}
+	missing := make(map[int]bool, len(numbers))
+	for _, number := range numbers {
+		missing[number] = true
 	}
 
-	return prs, nil
+	return func(yield func(*models.PullRequest, error) bool) {
+		for pr, err := range prs {
+			if err == nil && missing[pr.Number] {
+				yield(nil, fmt.Errorf("PR #%d of %s was %w; fetch them with pr-analyzer sync %s --include-diffs",
+					pr.Number, opts.Repo, ErrMissingPatches, opts.Repo))
+				return
+			}
+			if !yield(pr, err) {
+				return
+			}
+		}
+	}, nil
 }
 
-func (s *Service) exportData(prs []*models.PullRequest, opts AnalyzeOptions) (string, int64, error) {
+func (s *Service) exportData(prs iter.Seq2[*models.PullRequest, error], opts AnalyzeOptions) (string, int, int64, error) {
 	// Generate filename if not provided
 	filename := opts.Output
 	if filename == "" {
@@ -249,17 +460,18 @@ func (s *Service) exportData(prs []*models.PullRequest, opts AnalyzeOptions) (st
 	exporter := export.NewExporter(exportOpts)
 
 	// Export data
-	if err := exporter.Export(prs); err != nil {
-		return "", 0, fmt.Errorf("exporting data: %w", err)
+	count, err := exporter.Export(prs)
+	if err != nil {
+		return "", count, 0, fmt.Errorf("exporting data: %w", err)
 	}
 
 	// Get file size
 	fileSize, err := exporter.GetFileSize()
 	if err != nil {
-		return filename, 0, fmt.Errorf("getting file size: %w", err)
+		return filename, count, 0, fmt.Errorf("getting file size: %w", err)
 	}
 
-	return filename, fileSize, nil
+	return filename, count, fileSize, nil
 }
 
 func (s *Service) Close() error {
@@ -269,6 +481,18 @@ func (s *Service) Close() error {
 	return nil
 }
 
+// parseSince parses the --since date; an empty value means no cutoff.
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date format '%s': use YYYY-MM-DD", since)
+	}
+	return t, nil
+}
+
 func ensureCacheDir(dir string) error {
 	return os.MkdirAll(dir, 0750)
 }
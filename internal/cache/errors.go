@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIO marks failures reading or writing the cache database, such as a
+// locked, unwritable or corrupt file, as opposed to problems with its data.
+var ErrIO = errors.New("cache I/O error")
+
+// ioError wraps a database error so that it matches ErrIO.
+func ioError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrIO, err)
+}
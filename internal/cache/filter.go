@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter selects pull requests in QueryPullRequests. Zero fields match
+// every PR; list fields match PRs with any of their values.
+type Filter struct {
+	Number int
+	// State is "open" or "closed"
+	State      string
+	MergedOnly bool
+	// Authors and Reviewers are logins; they and Labels are compared
+	// case-insensitively, like the qualifiers of Query
+	Authors   []string
+	Reviewers []string
+	Labels    []string
+	// Paths are globs matched against changed file names, where ** also
+	// matches slashes; a plain directory matches the files below it
+	Paths       []string
+	Base        string
+	ExcludeBots bool
+	// Query is a search query applied on top of the other fields
+	Query *Query
+
+	UpdatedSince time.Time
+	CreatedSince time.Time
+	// CreatedUntil excludes PRs created at or after it
+	CreatedUntil time.Time
+
+	// Limit bounds the number of PRs returned; zero means no bound
+	Limit int
+}
+
+func (f Filter) apply(query *gorm.DB) *gorm.DB {
+	if f.Number > 0 {
+		query = query.Where("number = ?", f.Number)
+	}
+	if f.State != "" {
+		query = query.Where("state = ?", f.State)
+	}
+	if f.MergedOnly {
+		query = query.Where("merged_at IS NOT NULL")
+	}
+	if len(f.Authors) > 0 {
+		query = query.Where("LOWER(author) IN ?", lower(f.Authors))
+	}
+	if len(f.Reviewers) > 0 {
+		query = query.Where(`EXISTS (SELECT 1 FROM reviews WHERE reviews.repo = pulls.repo
+			AND reviews.pull_number = pulls.number AND LOWER(reviews.reviewer) IN ?)`, lower(f.Reviewers))
+	}
+	if len(f.Labels) > 0 {
+		query = query.Where(`EXISTS (SELECT 1 FROM json_each(pulls.labels)
+			WHERE LOWER(json_extract(json_each.value, '$.name')) IN ?)`, lower(f.Labels))
+	}
+	if len(f.Paths) > 0 {
+		globs := make([]string, len(f.Paths))
+		var args []any
+		for i, path := range f.Paths {
+			cond, pathArgs := pathCondition(path)
+			globs[i] = cond
+			args = append(args, pathArgs...)
+		}
+		query = query.Where(`EXISTS (SELECT 1 FROM files WHERE files.repo = pulls.repo
+			AND files.pull_number = pulls.number AND (`+strings.Join(globs, " OR ")+`))`, args...)
+	}
+	if f.Base != "" {
+		query = query.Where("base_ref = ?", f.Base)
+	}
+	if f.ExcludeBots {
+		query = query.Where("author_is_bot = ?", false)
+	}
+	if !f.UpdatedSince.IsZero() {
+		query = query.Where("updated_at >= ?", f.UpdatedSince)
+	}
+	if !f.CreatedSince.IsZero() {
+		query = query.Where("created_at >= ?", f.CreatedSince)
+	}
+	if !f.CreatedUntil.IsZero() {
+		query = query.Where("created_at < ?", f.CreatedUntil)
+	}
+	if f.Query != nil {
+		query = f.Query.apply(query)
+	}
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+	return query
+}
+
+func lower(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strings.ToLower(value)
+	}
+	return result
+}
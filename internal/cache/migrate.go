@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew is returned when the cache was written by a newer binary.
+var ErrSchemaTooNew = errors.New("cache schema is newer than this binary supports")
+
+// Migration is a single ordered schema change embedded in the binary.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// SchemaVersion records every migration applied to the cache.
+type SchemaVersion struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+const createSchemaVersionTable = "CREATE TABLE IF NOT EXISTS `schema_version` " +
+	"(`version` integer PRIMARY KEY, `name` text, `applied_at` datetime)"
+
+// MigrationPlan describes the state of a cache database relative to this binary.
+type MigrationPlan struct {
+	Current int
+	Latest  int
+	Pending []Migration
+}
+
+// Migrations returns all embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		// Files are named NNNN_description.sql
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration filename: %s", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// LatestVersion returns the schema version this binary migrates to.
+func LatestVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// CurrentVersion returns the schema version of db. Caches created before
+// versioning was introduced are detected from their table layout.
+func CurrentVersion(db *gorm.DB) (int, error) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&SchemaVersion{}) {
+		if migrator.HasTable("pulls") && migrator.HasColumn("pulls", "repo") {
+			// Created by AutoMigrate after repository scoping was added
+			return 2, nil
+		}
+		// Empty or original layout; the baseline migration is idempotent
+		return 0, nil
+	}
+
+	var version int
+	if err := db.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", ioError(err))
+	}
+	return version, nil
+}
+
+// PendingMigrations returns the migrations not yet applied to db.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if current > latest {
+		return nil, fmt.Errorf("%w: cache is at version %d, binary supports up to %d", ErrSchemaTooNew, current, latest)
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+// Migrate applies all pending migrations, each in its own transaction.
+func Migrate(db *gorm.DB) error {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Exec(createSchemaVersionTable).Error; err != nil {
+		return fmt.Errorf("creating schema_version table: %w", ioError(err))
+	}
+
+	// Record the detected baseline for caches that predate versioning
+	if current > 0 {
+		baseline := SchemaVersion{Version: current, Name: "baseline", AppliedAt: time.Now()}
+		if err := db.FirstOrCreate(&baseline, SchemaVersion{Version: current}).Error; err != nil {
+			return fmt.Errorf("recording baseline version: %w", ioError(err))
+		}
+	}
+
+	for _, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.SQL).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("applying migration %04d_%s: %w", m.Version, m.Name, ioError(err))
+		}
+	}
+
+	return nil
+}
+
+// PlanMigrations inspects the cache at dbPath without writing to it. A
+// missing cache is reported at version 0 with every migration pending and
+// is not created.
+func PlanMigrations(dbPath string) (*MigrationPlan, error) {
+	if _, err := os.Stat(dbPath); errors.Is(err, fs.ErrNotExist) {
+		migrations, err := Migrations()
+		if err != nil {
+			return nil, err
+		}
+		latest, err := LatestVersion()
+		if err != nil {
+			return nil, err
+		}
+		return &MigrationPlan{Current: 0, Latest: latest, Pending: migrations}, nil
+	}
+
+	db, err := openDB(readOnlyDSN(dbPath))
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB(db)
+
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrationPlan{Current: current, Latest: latest, Pending: pending}, nil
+}
+
+// readOnlyDSN opens the SQLite database at dbPath without write access.
+func readOnlyDSN(dbPath string) string {
+	dsn := &url.URL{Scheme: "file", Path: filepath.ToSlash(dbPath), RawQuery: "mode=ro"}
+	return dsn.String()
+}
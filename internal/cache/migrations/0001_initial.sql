@@ -0,0 +1,87 @@
+-- Baseline schema as created by the original GORM AutoMigrate setup.
+CREATE TABLE IF NOT EXISTS `pulls` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `number` integer,
+    `title` text,
+    `state` text,
+    `author` text,
+    `author_type` text,
+    `author_is_bot` numeric,
+    `assignees` text,
+    `requested_reviewers` text,
+    `labels` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `merged_at` datetime,
+    `last_fetched_at` datetime,
+    `raw_json` text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_pulls_number` ON `pulls`(`number`);
+
+CREATE TABLE IF NOT EXISTS `reviews` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `pull_number` integer,
+    `reviewer` text,
+    `reviewer_type` text,
+    `reviewer_is_bot` numeric,
+    `state` text,
+    `submitted_at` datetime,
+    `raw_json` text
+);
+CREATE INDEX IF NOT EXISTS `idx_reviews_pull_number` ON `reviews`(`pull_number`);
+
+CREATE TABLE IF NOT EXISTS `comments` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `pull_number` integer,
+    `review_id` integer,
+    `author` text,
+    `author_type` text,
+    `author_is_bot` numeric,
+    `body` text,
+    `path` text,
+    `line` integer,
+    `side` text,
+    `diff_hunk` text,
+    `reactions` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `in_reply_to_id` integer,
+    `raw_json` text
+);
+CREATE INDEX IF NOT EXISTS `idx_comments_pull_number` ON `comments`(`pull_number`);
+
+CREATE TABLE IF NOT EXISTS `files` (
+    `pull_number` integer,
+    `filename` text,
+    `status` text,
+    `additions` integer,
+    `deletions` integer,
+    `patch` text,
+    `raw_json` text,
+    PRIMARY KEY (`pull_number`, `filename`)
+);
+
+CREATE TABLE IF NOT EXISTS `sync_metadata` (
+    `repo` text,
+    `last_sync_at` datetime,
+    `last_pr_number` integer,
+    `total_p_rs` integer,
+    `open_p_rs` integer,
+    `closed_p_rs` integer,
+    PRIMARY KEY (`repo`)
+);
+
+CREATE TABLE IF NOT EXISTS `bot_patterns` (
+    `pattern` text,
+    `description` text,
+    PRIMARY KEY (`pattern`)
+);
+
+INSERT OR IGNORE INTO `bot_patterns` (`pattern`, `description`) VALUES
+    ('dependabot', 'Dependency updates'),
+    ('renovate', 'Dependency updates'),
+    ('snyk', 'Security scanning'),
+    ('codecov', 'Code coverage'),
+    ('github-actions', 'GitHub Actions bot'),
+    ('vercel', 'Vercel deployment bot'),
+    ('netlify', 'Netlify deployment bot');
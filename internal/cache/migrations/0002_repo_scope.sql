@@ -0,0 +1,111 @@
+-- Key every PR-related table by repository. Legacy rows carry no repository,
+-- so they are only kept when sync metadata names exactly one repo; otherwise
+-- they are dropped and refetched on the next run.
+DROP INDEX IF EXISTS `idx_pulls_number`;
+DROP INDEX IF EXISTS `idx_reviews_pull_number`;
+DROP INDEX IF EXISTS `idx_comments_pull_number`;
+
+ALTER TABLE `pulls` RENAME TO `legacy_pulls`;
+ALTER TABLE `reviews` RENAME TO `legacy_reviews`;
+ALTER TABLE `comments` RENAME TO `legacy_comments`;
+ALTER TABLE `files` RENAME TO `legacy_files`;
+
+CREATE TABLE `pulls` (
+    `repo` text,
+    `number` integer,
+    `id` integer,
+    `title` text,
+    `state` text,
+    `author` text,
+    `author_type` text,
+    `author_is_bot` numeric,
+    `assignees` text,
+    `requested_reviewers` text,
+    `labels` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `merged_at` datetime,
+    `last_fetched_at` datetime,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `number`)
+);
+CREATE INDEX `idx_pulls_id` ON `pulls`(`id`);
+
+CREATE TABLE `reviews` (
+    `repo` text,
+    `id` integer,
+    `pull_number` integer,
+    `reviewer` text,
+    `reviewer_type` text,
+    `reviewer_is_bot` numeric,
+    `state` text,
+    `submitted_at` datetime,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `id`)
+);
+CREATE INDEX `idx_reviews_pull` ON `reviews`(`repo`, `pull_number`);
+
+CREATE TABLE `comments` (
+    `repo` text,
+    `id` integer,
+    `pull_number` integer,
+    `review_id` integer,
+    `author` text,
+    `author_type` text,
+    `author_is_bot` numeric,
+    `body` text,
+    `path` text,
+    `line` integer,
+    `side` text,
+    `diff_hunk` text,
+    `reactions` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `in_reply_to_id` integer,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `id`)
+);
+CREATE INDEX `idx_comments_pull` ON `comments`(`repo`, `pull_number`);
+
+CREATE TABLE `files` (
+    `repo` text,
+    `pull_number` integer,
+    `filename` text,
+    `status` text,
+    `additions` integer,
+    `deletions` integer,
+    `patch` text,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `pull_number`, `filename`)
+);
+
+INSERT INTO `pulls`
+SELECT (SELECT `repo` FROM `sync_metadata`), `number`, `id`, `title`, `state`, `author`, `author_type`,
+       `author_is_bot`, `assignees`, `requested_reviewers`, `labels`, `created_at`, `updated_at`,
+       `merged_at`, `last_fetched_at`, `raw_json`
+FROM `legacy_pulls`
+WHERE (SELECT COUNT(*) FROM `sync_metadata`) = 1;
+
+INSERT INTO `reviews`
+SELECT (SELECT `repo` FROM `sync_metadata`), `id`, `pull_number`, `reviewer`, `reviewer_type`,
+       `reviewer_is_bot`, `state`, `submitted_at`, `raw_json`
+FROM `legacy_reviews`
+WHERE (SELECT COUNT(*) FROM `sync_metadata`) = 1;
+
+INSERT INTO `comments`
+SELECT (SELECT `repo` FROM `sync_metadata`), `id`, `pull_number`, `review_id`, `author`, `author_type`,
+       `author_is_bot`, `body`, `path`, `line`, `side`, `diff_hunk`, `reactions`, `created_at`,
+       `updated_at`, `in_reply_to_id`, `raw_json`
+FROM `legacy_comments`
+WHERE (SELECT COUNT(*) FROM `sync_metadata`) = 1;
+
+INSERT INTO `files`
+SELECT (SELECT `repo` FROM `sync_metadata`), `pull_number`, `filename`, `status`, `additions`,
+       `deletions`, `patch`, `raw_json`
+FROM `legacy_files`
+WHERE (SELECT COUNT(*) FROM `sync_metadata`) = 1;
+
+DROP TABLE `legacy_pulls`;
+DROP TABLE `legacy_reviews`;
+DROP TABLE `legacy_comments`;
+DROP TABLE `legacy_files`;
@@ -0,0 +1,13 @@
+-- Track whether the last sync listed every PR, so incremental runs know
+-- whether a full listing may stop at the previous sync time.
+ALTER TABLE `sync_metadata` ADD COLUMN `complete` numeric NOT NULL DEFAULT false;
+
+-- updated_at used to be overwritten with the local save time; restore
+-- GitHub's timestamps from the raw JSON so they can drive incremental syncs.
+UPDATE `pulls`
+SET `updated_at` = strftime('%Y-%m-%d %H:%M:%S+00:00', json_extract(`raw_json`, '$.updated_at'))
+WHERE json_extract(`raw_json`, '$.updated_at') IS NOT NULL;
+
+UPDATE `comments`
+SET `updated_at` = strftime('%Y-%m-%d %H:%M:%S+00:00', json_extract(`raw_json`, '$.updated_at'))
+WHERE json_extract(`raw_json`, '$.updated_at') IS NOT NULL;
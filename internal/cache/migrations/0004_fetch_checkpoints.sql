@@ -0,0 +1,16 @@
+-- Progress of an interrupted sync, one row per repository.
+CREATE TABLE `fetch_checkpoints` (
+    `repo` text,
+    `page` integer,
+    `listed` integer,
+    `last_completed_pr` integer,
+    `pending` text,
+    `limit` integer,
+    `refetch` numeric,
+    `cutoff` datetime,
+    `sync_cutoff` numeric,
+    `unbounded` numeric,
+    `started_at` datetime,
+    `updated_at` datetime,
+    PRIMARY KEY (`repo`)
+);
@@ -0,0 +1,11 @@
+-- Validators of cached list responses, used for conditional requests.
+CREATE TABLE `http_validators` (
+    `url` text,
+    `repo` text,
+    `etag` text,
+    `last_modified` text,
+    `link` text,
+    `updated_at` datetime,
+    PRIMARY KEY (`url`)
+);
+CREATE INDEX `idx_http_validators_repo` ON `http_validators`(`repo`);
@@ -0,0 +1,2 @@
+-- GraphQL listings resume from a cursor instead of a page number.
+ALTER TABLE `fetch_checkpoints` ADD COLUMN `cursor` text;
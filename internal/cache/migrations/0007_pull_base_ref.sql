@@ -0,0 +1,4 @@
+-- Record the base branch of each PR so that exports can filter on it. PRs
+-- cached before this migration have no base branch until they are fetched again.
+ALTER TABLE `pulls` ADD COLUMN `base_ref` text;
+CREATE INDEX `idx_pulls_base_ref` ON `pulls`(`repo`, `base_ref`);
@@ -0,0 +1,14 @@
+-- Record the description, branches, draft flag and merge details of each PR.
+-- PRs cached before this migration have none of them until they are fetched again.
+ALTER TABLE `pulls` ADD COLUMN `body` text;
+ALTER TABLE `pulls` ADD COLUMN `draft` numeric;
+ALTER TABLE `pulls` ADD COLUMN `author_association` text;
+ALTER TABLE `pulls` ADD COLUMN `requested_teams` text;
+ALTER TABLE `pulls` ADD COLUMN `milestone` text;
+ALTER TABLE `pulls` ADD COLUMN `closed_at` datetime;
+ALTER TABLE `pulls` ADD COLUMN `merged_by` text;
+ALTER TABLE `pulls` ADD COLUMN `merge_commit_sha` text;
+ALTER TABLE `pulls` ADD COLUMN `base_sha` text;
+ALTER TABLE `pulls` ADD COLUMN `head_ref` text;
+ALTER TABLE `pulls` ADD COLUMN `head_sha` text;
+CREATE INDEX `idx_pulls_head_ref` ON `pulls`(`repo`, `head_ref`);
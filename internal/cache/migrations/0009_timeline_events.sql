@@ -0,0 +1,13 @@
+-- Timeline events of each PR, such as review requests, draft changes and
+-- force pushes, for measuring how long PRs spend in each stage.
+CREATE TABLE `timeline_events` (
+    `repo` text,
+    `id` integer,
+    `pull_number` integer,
+    `event` text,
+    `actor` text,
+    `created_at` datetime,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `id`)
+);
+CREATE INDEX `idx_timeline_events_pull` ON `timeline_events`(`repo`, `pull_number`);
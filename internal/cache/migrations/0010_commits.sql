@@ -0,0 +1,16 @@
+-- Commits of each PR. A commit can belong to several PRs, so rows are keyed
+-- by PR; position keeps the order GitHub lists them in.
+CREATE TABLE `commits` (
+    `repo` text,
+    `pull_number` integer,
+    `sha` text,
+    `position` integer,
+    `author` text,
+    `author_email` text,
+    `committer` text,
+    `authored_at` datetime,
+    `committed_at` datetime,
+    `verified` numeric,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `pull_number`, `sha`)
+);
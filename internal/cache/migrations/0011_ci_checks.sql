@@ -0,0 +1,17 @@
+-- Check runs and commit statuses of the head commit of each PR. Re-runs
+-- and the results of earlier head commits are kept to measure CI time.
+CREATE TABLE `ci_checks` (
+    `repo` text,
+    `pull_number` integer,
+    `kind` text,
+    `id` integer,
+    `head_sha` text,
+    `name` text,
+    `status` text,
+    `conclusion` text,
+    `app` text,
+    `started_at` datetime,
+    `completed_at` datetime,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `pull_number`, `kind`, `id`)
+);
@@ -0,0 +1,12 @@
+-- Resolution state of each review thread, keyed by the thread's first
+-- comment. REST comments do not carry it, so it is fetched with GraphQL.
+CREATE TABLE `review_threads` (
+    `repo` text,
+    `pull_number` integer,
+    `root_id` integer,
+    `is_resolved` numeric,
+    `resolved_by` text,
+    `is_outdated` numeric,
+    `raw_json` text,
+    PRIMARY KEY (`repo`, `pull_number`, `root_id`)
+);
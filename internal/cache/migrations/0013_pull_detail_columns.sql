@@ -0,0 +1,8 @@
+-- The PR listing leaves out the number of commits and who merged a PR. The
+-- detail fetch updates these columns in place, and they take precedence
+-- over raw_json when a PR is read.
+ALTER TABLE `pulls` ADD COLUMN `commit_count` integer;
+ALTER TABLE `pulls` ADD COLUMN `merged_by_type` text;
+UPDATE `pulls` SET
+    `commit_count` = json_extract(`raw_json`, '$.stats.commits'),
+    `merged_by_type` = json_extract(`raw_json`, '$.merged_by.type');
@@ -0,0 +1,3 @@
+-- Marks PRs whose files were cached from GraphQL, which has no patches, so
+-- that an export with diffs fetches them from REST first.
+ALTER TABLE `pulls` ADD COLUMN `missing_patches` numeric;
@@ -0,0 +1,15 @@
+-- GitHub computes ETags per credential, so validators are kept per URL and
+-- credential. Validators stored before are dropped; the pages they belong to
+-- are downloaded once more.
+DROP TABLE `http_validators`;
+CREATE TABLE `http_validators` (
+    `url` text,
+    `credential` text,
+    `repo` text,
+    `etag` text,
+    `last_modified` text,
+    `link` text,
+    `updated_at` datetime,
+    PRIMARY KEY (`url`, `credential`)
+);
+CREATE INDEX `idx_http_validators_repo` ON `http_validators`(`repo`);
@@ -2,31 +2,46 @@ package cache
 
 import (
 	"time"
-
-	"gorm.io/gorm"
 )
 
 type Pull struct {
-	ID                 int64 `gorm:"primaryKey"`
-	Number             int   `gorm:"uniqueIndex"`
+	Repo               string `gorm:"primaryKey"`
+	Number             int    `gorm:"primaryKey;autoIncrement:false"`
+	ID                 int64  `gorm:"index"`
 	Title              string
 	State              string
 	Author             string
 	AuthorType         string
 	AuthorIsBot        bool
-	Assignees          string // JSON array
-	RequestedReviewers string // JSON array
-	Labels             string // JSON array
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
+	Assignees          string    // JSON array
+	RequestedReviewers string    // JSON array
+	Labels             string    // JSON array
+	CreatedAt          time.Time `gorm:"autoCreateTime:false"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime:false"`
 	MergedAt           *time.Time
+	BaseRef            string
+	Body               string `gorm:"type:text"`
+	Draft              bool
+	AuthorAssociation  string
+	RequestedTeams     string // JSON array
+	Milestone          string
+	ClosedAt           *time.Time
+	MergedBy           string
+	MergedByType       string
+	MergeCommitSHA     string `gorm:"column:merge_commit_sha"`
+	BaseSHA            string `gorm:"column:base_sha"`
+	HeadRef            string
+	HeadSHA            string `gorm:"column:head_sha"`
+	CommitCount        int
+	MissingPatches     bool
 	LastFetchedAt      time.Time
 	RawJSON            string
 }
 
 type Review struct {
-	ID            int64 `gorm:"primaryKey"`
-	PullNumber    int   `gorm:"index"`
+	Repo          string `gorm:"primaryKey;index:idx_reviews_pull,priority:1"`
+	ID            int64  `gorm:"primaryKey;autoIncrement:false"`
+	PullNumber    int    `gorm:"index:idx_reviews_pull,priority:2"`
 	Reviewer      string
 	ReviewerType  string
 	ReviewerIsBot bool
@@ -36,8 +51,9 @@ type Review struct {
 }
 
 type Comment struct {
-	ID          int64 `gorm:"primaryKey"`
-	PullNumber  int   `gorm:"index"`
+	Repo        string `gorm:"primaryKey;index:idx_comments_pull,priority:1"`
+	ID          int64  `gorm:"primaryKey;autoIncrement:false"`
+	PullNumber  int    `gorm:"index:idx_comments_pull,priority:2"`
 	ReviewID    *int64
 	Author      string
 	AuthorType  string
@@ -46,15 +62,26 @@ type Comment struct {
 	Path        string
 	Line        *int
 	Side        string
-	DiffHunk    string `gorm:"type:text"`
-	Reactions   string // JSON object
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	DiffHunk    string    `gorm:"type:text"`
+	Reactions   string    // JSON object
+	CreatedAt   time.Time `gorm:"autoCreateTime:false"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime:false"`
 	InReplyToID *int64
 	RawJSON     string `gorm:"type:text"`
 }
 
+type ReviewThread struct {
+	Repo       string `gorm:"primaryKey"`
+	PullNumber int    `gorm:"primaryKey;autoIncrement:false"`
+	RootID     int64  `gorm:"primaryKey;autoIncrement:false"`
+	IsResolved bool
+	ResolvedBy string
+	IsOutdated bool
+	RawJSON    string `gorm:"type:text"`
+}
+
 type File struct {
+	Repo       string `gorm:"primaryKey"`
 	PullNumber int    `gorm:"primaryKey;autoIncrement:false"`
 	Filename   string `gorm:"primaryKey"`
 	Status     string
@@ -64,6 +91,49 @@ type File struct {
 	RawJSON    string `gorm:"type:text"`
 }
 
+type Commit struct {
+	Repo        string `gorm:"primaryKey"`
+	PullNumber  int    `gorm:"primaryKey;autoIncrement:false"`
+	SHA         string `gorm:"primaryKey;column:sha"`
+	Position    int
+	Author      string
+	AuthorEmail string
+	Committer   string
+	AuthoredAt  time.Time
+	CommittedAt time.Time
+	Verified    bool
+	RawJSON     string `gorm:"type:text"`
+}
+
+type CheckRun struct {
+	Repo        string `gorm:"primaryKey"`
+	PullNumber  int    `gorm:"primaryKey;autoIncrement:false"`
+	Kind        string `gorm:"primaryKey"`
+	ID          int64  `gorm:"primaryKey;autoIncrement:false"`
+	HeadSHA     string `gorm:"column:head_sha"`
+	Name        string
+	Status      string
+	Conclusion  string
+	App         string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	RawJSON     string `gorm:"type:text"`
+}
+
+func (CheckRun) TableName() string {
+	return "ci_checks"
+}
+
+type TimelineEvent struct {
+	Repo       string `gorm:"primaryKey;index:idx_timeline_events_pull,priority:1"`
+	ID         int64  `gorm:"primaryKey;autoIncrement:false"`
+	PullNumber int    `gorm:"index:idx_timeline_events_pull,priority:2"`
+	Event      string
+	Actor      string
+	CreatedAt  time.Time `gorm:"autoCreateTime:false"`
+	RawJSON    string    `gorm:"type:text"`
+}
+
 type SyncMetadata struct {
 	Repo         string `gorm:"primaryKey"`
 	LastSyncAt   time.Time
@@ -71,40 +141,39 @@ type SyncMetadata struct {
 	TotalPRs     int
 	OpenPRs      int
 	ClosedPRs    int
+	Complete     bool
 }
 
-type BotPattern struct {
-	Pattern     string `gorm:"primaryKey"`
-	Description string
+type FetchCheckpoint struct {
+	Repo            string `gorm:"primaryKey"`
+	Page            int
+	Cursor          string
+	Listed          int
+	LastCompletedPR int    `gorm:"column:last_completed_pr"`
+	Pending         string // JSON array of PR numbers
+	Limit           int
+	Refetch         bool
+	Cutoff          *time.Time
+	SyncCutoff      bool
+	Unbounded       bool
+	StartedAt       time.Time
+	UpdatedAt       time.Time `gorm:"autoUpdateTime:false"`
 }
 
-func Migrate(db *gorm.DB) error {
-	// Auto migrate all tables
-	if err := db.AutoMigrate(
-		&Pull{},
-		&Review{},
-		&Comment{},
-		&File{},
-		&SyncMetadata{},
-		&BotPattern{},
-	); err != nil {
-		return err
-	}
-
-	// Add initial bot patterns
-	botPatterns := []BotPattern{
-		{Pattern: "dependabot", Description: "Dependency updates"},
-		{Pattern: "renovate", Description: "Dependency updates"},
-		{Pattern: "snyk", Description: "Security scanning"},
-		{Pattern: "codecov", Description: "Code coverage"},
-		{Pattern: "github-actions", Description: "GitHub Actions bot"},
-		{Pattern: "vercel", Description: "Vercel deployment bot"},
-		{Pattern: "netlify", Description: "Netlify deployment bot"},
-	}
-
-	for _, pattern := range botPatterns {
-		db.FirstOrCreate(&pattern, BotPattern{Pattern: pattern.Pattern})
-	}
+// HTTPValidator holds the validators and pagination links of the last full
+// response for a request URL and credential, so that it can be fetched
+// conditionally.
+type HTTPValidator struct {
+	URL          string `gorm:"primaryKey"`
+	Credential   string `gorm:"primaryKey"`
+	Repo         string `gorm:"index"`
+	ETag         string `gorm:"column:etag"`
+	LastModified string
+	Link         string
+	UpdatedAt    time.Time `gorm:"autoUpdateTime:false"`
+}
 
-	return nil
+type BotPattern struct {
+	Pattern     string `gorm:"primaryKey"`
+	Description string
 }
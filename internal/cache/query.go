@@ -0,0 +1,427 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidQuery is returned for search queries that cannot be parsed or
+// use qualifiers the cache cannot answer.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Query is a search query in the syntax of GitHub's issue search, such as
+//
+//	is:merged author:alice label:bug -label:wip created:>2025-01-01 path:src/**
+//
+// All terms must match; a leading - negates a term. Words without a
+// qualifier are searched for in PR titles.
+type Query struct {
+	terms []queryTerm
+}
+
+type queryTerm struct {
+	raw    string
+	negate bool
+	cond   string
+	args   []any
+}
+
+// qualifiers translates the value of each supported qualifier into a SQL
+// condition on the pulls table.
+var qualifiers = map[string]func(value string) (string, []any, error){
+	"is":                    qualifyIs,
+	"state":                 qualifyState,
+	"author":                qualifyAuthor,
+	"assignee":              jsonLoginQualifier("assignees"),
+	"review-requested":      jsonLoginQualifier("requested_reviewers"),
+	"team-review-requested": qualifyTeamReviewRequested,
+	"reviewed-by":           relatedLoginQualifier("reviews", "reviewer"),
+	"commenter":             relatedLoginQualifier("comments", "author"),
+	"label":                 qualifyLabel,
+	"base":                  qualifyBase,
+	"head":                  qualifyHead,
+	"draft":                 qualifyDraft,
+	"milestone":             qualifyMilestone,
+	"path":                  qualifyPath,
+	"created":               dateQualifier("created_at"),
+	"updated":               dateQualifier("updated_at"),
+	"merged":                dateQualifier("merged_at"),
+	"in":                    qualifyIn,
+	"no":                    qualifyNo,
+	"type":                  qualifyType,
+	"repo":                  nil,
+	"sort":                  nil,
+	"project":               nil,
+	"review":                nil,
+}
+
+// ParseQuery parses a search query. Qualifiers GitHub supports but the
+// cache has no data for are rejected rather than silently ignored.
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{}
+	for _, token := range tokens {
+		term, err := parseTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+// tokenize splits s at unquoted whitespace and removes the quotes, so that
+// label:"good first issue" is a single token.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inQuotes, started := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			started = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if started {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("%w: unterminated quote in %q", ErrInvalidQuery, s)
+	}
+	if started {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+func parseTerm(token string) (queryTerm, error) {
+	term := queryTerm{raw: token}
+	if len(token) > 1 && token[0] == '-' {
+		term.negate = true
+		token = token[1:]
+	}
+
+	name, value, ok := strings.Cut(token, ":")
+	if !ok || name == "" {
+		// A bare word searches titles
+		term.cond = "title LIKE ? ESCAPE '\\'"
+		term.args = []any{"%" + escapeLike(token) + "%"}
+		return term, nil
+	}
+
+	qualify, known := qualifiers[strings.ToLower(name)]
+	if qualify == nil {
+		if known {
+			return term, fmt.Errorf("%w: %s: the %q qualifier is not supported for cached data", ErrInvalidQuery, term.raw, name)
+		}
+		return term, fmt.Errorf("%w: %s: unknown qualifier %q (supported: %s)", ErrInvalidQuery, term.raw, name, supportedQualifiers())
+	}
+	if value == "" {
+		return term, fmt.Errorf("%w: %s: missing value", ErrInvalidQuery, term.raw)
+	}
+
+	cond, args, err := qualify(value)
+	if err != nil {
+		return term, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, term.raw, err)
+	}
+	term.cond, term.args = cond, args
+	return term, nil
+}
+
+func supportedQualifiers() string {
+	var names []string
+	for name, qualify := range qualifiers {
+		if qualify != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (q *Query) apply(query *gorm.DB) *gorm.DB {
+	for _, term := range q.terms {
+		if term.negate {
+			// A NULL column makes a condition NULL rather than false;
+			// negate it as false so that the row still matches
+			query = query.Where("NOT COALESCE(("+term.cond+"), 0)", term.args...)
+		} else {
+			query = query.Where("("+term.cond+")", term.args...)
+		}
+	}
+	return query
+}
+
+func qualifyIs(value string) (string, []any, error) {
+	switch strings.ToLower(value) {
+	case "open", "closed":
+		return qualifyState(value)
+	case "merged":
+		return "merged_at IS NOT NULL", nil, nil
+	case "draft":
+		return qualifyDraft("true")
+	case "unmerged":
+		return "state = 'closed' AND merged_at IS NULL", nil, nil
+	case "pr":
+		return "1 = 1", nil, nil
+	case "issue":
+		return "1 = 0", nil, nil
+	}
+	return "", nil, fmt.Errorf("use open, closed, merged, unmerged, draft or pr")
+}
+
+func qualifyType(value string) (string, []any, error) {
+	switch strings.ToLower(value) {
+	case "pr":
+		return "1 = 1", nil, nil
+	case "issue":
+		return "1 = 0", nil, nil
+	}
+	return "", nil, fmt.Errorf("use pr or issue")
+}
+
+func qualifyState(value string) (string, []any, error) {
+	value = strings.ToLower(value)
+	if value != "open" && value != "closed" {
+		return "", nil, fmt.Errorf("use open or closed")
+	}
+	return "state = ?", []any{value}, nil
+}
+
+func qualifyAuthor(value string) (string, []any, error) {
+	// GitHub names apps author:app/name; their logins end in [bot]
+	if app, ok := strings.CutPrefix(value, "app/"); ok {
+		value = app + "[bot]"
+	}
+	return "LOWER(author) = ?", []any{strings.ToLower(value)}, nil
+}
+
+// jsonLoginQualifier matches a login in a JSON array of users on pulls.
+func jsonLoginQualifier(column string) func(string) (string, []any, error) {
+	return func(value string) (string, []any, error) {
+		return "EXISTS (SELECT 1 FROM json_each(pulls." + column + ") WHERE LOWER(json_extract(json_each.value, '$.login')) = ?)",
+			[]any{strings.ToLower(value)}, nil
+	}
+}
+
+// relatedLoginQualifier matches PRs with a row in table by the given login.
+func relatedLoginQualifier(table, column string) func(string) (string, []any, error) {
+	return func(value string) (string, []any, error) {
+		return "EXISTS (SELECT 1 FROM " + table + " WHERE " + table + ".repo = pulls.repo AND " +
+				table + ".pull_number = pulls.number AND LOWER(" + table + "." + column + ") = ?)",
+			[]any{strings.ToLower(value)}, nil
+	}
+}
+
+func qualifyLabel(value string) (string, []any, error) {
+	// label:bug,wip matches either label, as on GitHub
+	names := strings.Split(strings.ToLower(value), ",")
+	args := make([]any, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	return "EXISTS (SELECT 1 FROM json_each(pulls.labels) WHERE LOWER(json_extract(json_each.value, '$.name')) IN (" +
+		placeholders(len(names)) + "))", args, nil
+}
+
+func qualifyBase(value string) (string, []any, error) {
+	return "base_ref = ?", []any{value}, nil
+}
+
+func qualifyHead(value string) (string, []any, error) {
+	return "head_ref = ?", []any{value}, nil
+}
+
+func qualifyDraft(value string) (string, []any, error) {
+	switch strings.ToLower(value) {
+	case "true":
+		return "draft", nil, nil
+	case "false":
+		return "NOT COALESCE(draft, 0)", nil, nil
+	}
+	return "", nil, fmt.Errorf("use true or false")
+}
+
+func qualifyMilestone(value string) (string, []any, error) {
+	return "milestone = ?", []any{value}, nil
+}
+
+// qualifyTeamReviewRequested matches org/team or a bare team slug.
+func qualifyTeamReviewRequested(value string) (string, []any, error) {
+	if _, team, ok := strings.Cut(value, "/"); ok {
+		value = team
+	}
+	return "EXISTS (SELECT 1 FROM json_each(pulls.requested_teams) WHERE LOWER(json_each.value) = ?)",
+		[]any{strings.ToLower(value)}, nil
+}
+
+func qualifyPath(value string) (string, []any, error) {
+	cond, args := pathCondition(value)
+	return "EXISTS (SELECT 1 FROM files WHERE files.repo = pulls.repo AND files.pull_number = pulls.number AND " + cond + ")",
+		args, nil
+}
+
+// pathCondition matches files.filename against a glob in which * and ?
+// stay within a path segment and ** crosses segments, so **/main.go also
+// matches a main.go at the root. A path without glob characters matches
+// that file or everything below that directory.
+func pathCondition(glob string) (string, []any) {
+	glob = strings.TrimPrefix(glob, "/")
+	if !strings.ContainsAny(glob, "*?[") {
+		dir := strings.TrimSuffix(glob, "/")
+		return "(files.filename = ? OR substr(files.filename, 1, ?) = ?)", []any{dir, len(dir) + 1, dir + "/"}
+	}
+
+	// SQLite's GLOB lets * match slashes. Without **, requiring as many
+	// slashes as the pattern has keeps every * within its segment.
+	if strings.Contains(glob, "**") {
+		var conds []string
+		var args []any
+		for _, alt := range expandDoubleStar(glob) {
+			conds = append(conds, "files.filename GLOB ?")
+			args = append(args, alt)
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", args
+	}
+	return "(files.filename GLOB ? AND length(files.filename) - length(replace(files.filename, '/', '')) = ?)",
+		[]any{glob, strings.Count(glob, "/")}
+}
+
+// expandDoubleStar turns a ** glob into SQLite globs. A **/ segment also
+// matches no directory at all, so each one yields a glob without it and
+// one where * stands for any number of directories.
+func expandDoubleStar(glob string) []string {
+	parts := strings.Split(glob, "**/")
+	alts := []string{parts[0]}
+	for _, part := range parts[1:] {
+		next := make([]string, 0, 2*len(alts))
+		for _, alt := range alts {
+			next = append(next, alt+part, alt+"*/"+part)
+		}
+		alts = next
+	}
+
+	for i, alt := range alts {
+		for strings.Contains(alt, "**") {
+			alt = strings.ReplaceAll(alt, "**", "*")
+		}
+		alts[i] = alt
+	}
+	return alts
+}
+
+func qualifyIn(value string) (string, []any, error) {
+	if strings.ToLower(value) != "title" {
+		return "", nil, fmt.Errorf("only titles are cached; use in:title")
+	}
+	return "1 = 1", nil, nil
+}
+
+func qualifyNo(value string) (string, []any, error) {
+	switch strings.ToLower(value) {
+	case "label":
+		return "labels IS NULL OR labels IN ('null', '[]')", nil, nil
+	case "assignee":
+		return "assignees IS NULL OR assignees IN ('null', '[]')", nil, nil
+	case "milestone":
+		return "milestone IS NULL OR milestone = ''", nil, nil
+	}
+	return "", nil, fmt.Errorf("use no:label, no:assignee or no:milestone")
+}
+
+// dateQualifier parses GitHub's date ranges: 2025-01-01, >2025-01-01,
+// >=, <, <=, 2025-01-01..2025-02-01 and open ranges with *.
+func dateQualifier(column string) func(string) (string, []any, error) {
+	return func(value string) (string, []any, error) {
+		if from, to, ok := strings.Cut(value, ".."); ok {
+			var conds []string
+			var args []any
+			if from != "*" {
+				start, _, err := parseQueryDate(from)
+				if err != nil {
+					return "", nil, err
+				}
+				conds = append(conds, column+" >= ?")
+				args = append(args, start)
+			}
+			if to != "*" {
+				_, end, err := parseQueryDate(to)
+				if err != nil {
+					return "", nil, err
+				}
+				conds = append(conds, column+" < ?")
+				args = append(args, end)
+			}
+			if len(conds) == 0 {
+				return column + " IS NOT NULL", nil, nil
+			}
+			return strings.Join(conds, " AND "), args, nil
+		}
+
+		for _, op := range []string{">=", "<=", ">", "<"} {
+			rest, ok := strings.CutPrefix(value, op)
+			if !ok {
+				continue
+			}
+			start, end, err := parseQueryDate(rest)
+			if err != nil {
+				return "", nil, err
+			}
+			switch op {
+			case ">=":
+				return column + " >= ?", []any{start}, nil
+			case ">":
+				return column + " >= ?", []any{end}, nil
+			case "<=":
+				return column + " < ?", []any{end}, nil
+			default:
+				return column + " < ?", []any{start}, nil
+			}
+		}
+
+		start, end, err := parseQueryDate(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return column + " >= ? AND " + column + " < ?", []any{start, end}, nil
+	}
+}
+
+// parseQueryDate returns the span covered by a date or timestamp: a whole
+// day for YYYY-MM-DD, a single instant otherwise.
+func parseQueryDate(value string) (time.Time, time.Time, error) {
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	if instant, err := time.Parse(time.RFC3339, value); err == nil {
+		instant = instant.UTC()
+		return instant, instant.Add(time.Second), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or an RFC 3339 timestamp", value)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
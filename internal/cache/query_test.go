@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+// newQueryFixture caches a handful of PRs of golang/go covering every
+// qualifier.
+func newQueryFixture(t *testing.T) *Store {
+	t.Helper()
+	store := newTestStore(t)
+	const repo = "golang/go"
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC) }
+	merged := day(6)
+
+	pulls := []*models.PullRequest{
+		{Number: 1, Title: "net/http: fix 100% CPU", State: "closed", Author: models.User{Login: "alice"}, BaseRef: "master",
+			Labels: []models.Label{{Name: "bug"}}, CreatedAt: day(1), UpdatedAt: day(6), MergedAt: &merged},
+		{Number: 2, Title: "cmd/go: add flag", State: "open", Draft: true, Author: models.User{Login: "Bob"}, BaseRef: "master",
+			HeadRef: "bob/flag", RequestedTeams: []string{"compiler"}, Milestone: "Go1.25",
+			Labels:             []models.Label{{Name: "bug"}, {Name: "WIP"}},
+			Assignees:          []models.User{{Login: "alice"}},
+			RequestedReviewers: []models.User{{Login: "carol"}},
+			CreatedAt:          day(3), UpdatedAt: day(7)},
+		{Number: 3, Title: "all: update dependencies", State: "closed", Author: models.User{Login: "dependabot[bot]"},
+			BaseRef: "release-branch.go1.24", CreatedAt: day(5), UpdatedAt: day(5)},
+		{Number: 4, Title: "doc: good first issue", State: "open", Author: models.User{Login: "dave"}, BaseRef: "master",
+			Labels: []models.Label{{Name: "good first issue"}}, CreatedAt: day(8), UpdatedAt: day(9)},
+	}
+	for _, pr := range pulls {
+		if err := store.SavePullRequest(repo, pr); err != nil {
+			t.Fatalf("SavePullRequest(%d) error = %v", pr.Number, err)
+		}
+	}
+	if err := store.SaveReview(repo, &models.Review{ID: 1, PullNumber: 1, Reviewer: models.User{Login: "bob"}}); err != nil {
+		t.Fatalf("SaveReview() error = %v", err)
+	}
+	if err := store.SaveComment(repo, &models.Comment{ID: 1, PullNumber: 2, Author: models.User{Login: "erin"}}); err != nil {
+		t.Fatalf("SaveComment() error = %v", err)
+	}
+	for _, file := range []*models.File{
+		{PullNumber: 1, Filename: "src/net/http/server.go"},
+		{PullNumber: 2, Filename: "src/cmd/go/main.go"},
+		{PullNumber: 3, Filename: "go.mod"},
+		{PullNumber: 3, Filename: "main.go"},
+		{PullNumber: 4, Filename: "doc/go_spec.html"},
+	} {
+		if err := store.SaveFile(repo, file); err != nil {
+			t.Fatalf("SaveFile() error = %v", err)
+		}
+	}
+	return store
+}
+
+func TestQueryMatchesGitHubSearchSyntax(t *testing.T) {
+	store := newQueryFixture(t)
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{"is:pr", []int{1, 2, 3, 4}},
+		{"is:open", []int{2, 4}},
+		{"state:closed", []int{1, 3}},
+		{"is:merged", []int{1}},
+		{"is:unmerged", []int{3}},
+		{"author:bob", []int{2}},
+		{"-author:bob", []int{1, 3, 4}},
+		{"author:app/dependabot", []int{3}},
+		{"assignee:alice", []int{2}},
+		{"review-requested:carol", []int{2}},
+		{"reviewed-by:BOB", []int{1}},
+		{"commenter:erin", []int{2}},
+		{"label:bug", []int{1, 2}},
+		{"label:bug -label:wip", []int{1}},
+		{"label:wip,good-first-issue", []int{2}},
+		{`label:"good first issue"`, []int{4}},
+		{"no:label", []int{3}},
+		{"base:master", []int{1, 2, 4}},
+		{"head:bob/flag", []int{2}},
+		{"is:draft", []int{2}},
+		{"draft:false", []int{1, 3, 4}},
+		{"milestone:Go1.25", []int{2}},
+		{"no:milestone", []int{1, 3, 4}},
+		{"team-review-requested:golang/compiler", []int{2}},
+		{"path:src/**", []int{1, 2}},
+		{"path:src/*.go", nil},
+		{"path:*.mod", []int{3}},
+		{"path:**/main.go", []int{2, 3}},
+		{"path:src/**/main.go", []int{2}},
+		{"path:src/cmd/**/main.go", []int{2}},
+		{"path:src/net", []int{1}},
+		{"-path:src/**", []int{3, 4}},
+		{"created:>2025-01-03", []int{3, 4}},
+		{"created:>=2025-01-03", []int{2, 3, 4}},
+		{"created:<2025-01-03", []int{1}},
+		{"created:<=2025-01-03", []int{1, 2}},
+		{"created:2025-01-05", []int{3}},
+		{"created:2025-01-02..2025-01-05", []int{2, 3}},
+		{"created:2025-01-05..*", []int{3, 4}},
+		{"updated:>2025-01-06T00:00:00Z", []int{1, 2, 4}},
+		{"merged:2025-01-01..2025-01-31", []int{1}},
+		{"-merged:2025-01-01..2025-01-31", []int{2, 3, 4}},
+		{"cmd/go", []int{2}},
+		{`"100%"`, []int{1}},
+		{"is:merged author:alice label:bug -label:wip reviewed-by:bob created:>2024-12-31 path:src/**", []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+			prs, err := store.QueryPullRequests("golang/go", Filter{Query: q})
+			if err != nil {
+				t.Fatalf("QueryPullRequests() error = %v", err)
+			}
+			var got []int
+			for _, pr := range prs {
+				got = append(got, pr.Number)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%q matched %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryRejectsUnsupportedTerms(t *testing.T) {
+	for _, query := range []string{
+		"project:roadmap",
+		"sort:updated-desc",
+		"frobnicate:yes",
+		"draft:maybe",
+		"author:",
+		"created:>yesterday",
+		"created:2025-13-01..2025-02-01",
+		`label:"unterminated`,
+		"in:body",
+	} {
+		if _, err := ParseQuery(query); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParseQuery(%q) error = %v, want ErrInvalidQuery", query, err)
+		}
+	}
+}
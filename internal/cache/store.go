@@ -3,6 +3,8 @@ package cache
 import (
 	"encoding/json"
 	"fmt"
+	"iter"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,29 +20,53 @@ type Store struct {
 }
 
 func NewStore(dbPath string) (*Store, error) {
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+	db, err := openDB(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("opening database: %w", err)
+		return nil, err
 	}
 
 	if err := Migrate(db); err != nil {
+		closeDB(db)
 		return nil, fmt.Errorf("migrating database: %w", err)
 	}
 
 	store := &Store{db: db}
 	if err := store.loadBotPatterns(); err != nil {
+		closeDB(db)
 		return nil, fmt.Errorf("loading bot patterns: %w", err)
 	}
 
 	return store, nil
 }
 
+func openDB(dbPath string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", ioError(err))
+	}
+
+	// SQLite allows a single writer; serialize access from concurrent fetchers
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", ioError(err))
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
 func (s *Store) loadBotPatterns() error {
 	var patterns []BotPattern
 	if err := s.db.Find(&patterns).Error; err != nil {
-		return err
+		return ioError(err)
 	}
 
 	s.botPatterns = make([]string, len(patterns))
@@ -61,7 +87,7 @@ func (s *Store) isBot(username string) bool {
 	return strings.HasSuffix(lowerUsername, "[bot]")
 }
 
-func (s *Store) SavePullRequest(pr *models.PullRequest) error {
+func (s *Store) SavePullRequest(repo string, pr *models.PullRequest) error {
 	rawJSON, err := json.Marshal(pr)
 	if err != nil {
 		return fmt.Errorf("marshaling raw JSON: %w", err)
@@ -70,10 +96,18 @@ func (s *Store) SavePullRequest(pr *models.PullRequest) error {
 	assignees, _ := json.Marshal(pr.Assignees)
 	reviewers, _ := json.Marshal(pr.RequestedReviewers)
 	labels, _ := json.Marshal(pr.Labels)
+	teams, _ := json.Marshal(pr.RequestedTeams)
+
+	var mergedBy, mergedByType string
+	if pr.MergedBy != nil {
+		mergedBy = pr.MergedBy.Login
+		mergedByType = pr.MergedBy.Type
+	}
 
 	cachePR := &Pull{
-		ID:                 pr.ID,
+		Repo:               repo,
 		Number:             pr.Number,
+		ID:                 pr.ID,
 		Title:              pr.Title,
 		State:              pr.State,
 		Author:             pr.Author.Login,
@@ -85,20 +119,94 @@ func (s *Store) SavePullRequest(pr *models.PullRequest) error {
 		CreatedAt:          pr.CreatedAt,
 		UpdatedAt:          pr.UpdatedAt,
 		MergedAt:           pr.MergedAt,
+		BaseRef:            pr.BaseRef,
+		Body:               pr.Body,
+		Draft:              pr.Draft,
+		AuthorAssociation:  pr.AuthorAssociation,
+		RequestedTeams:     string(teams),
+		Milestone:          pr.Milestone,
+		ClosedAt:           pr.ClosedAt,
+		MergedBy:           mergedBy,
+		MergedByType:       mergedByType,
+		MergeCommitSHA:     pr.MergeCommitSHA,
+		BaseSHA:            pr.BaseSHA,
+		HeadRef:            pr.HeadRef,
+		HeadSHA:            pr.HeadSHA,
+		CommitCount:        pr.Stats.Commits,
 		LastFetchedAt:      time.Now(),
 		RawJSON:            string(rawJSON),
 	}
 
-	return s.db.Save(cachePR).Error
+	// The listing leaves out who merged a PR and its number of commits, so
+	// keep the values SetPullMergedBy and SetPullCommitCount recorded.
+	// Whether patches are missing is only set by SetPullMissingPatches.
+	omit := []string{"missing_patches"}
+	if pr.Stats.Commits == 0 {
+		omit = append(omit, "commit_count")
+	}
+	if pr.MergedBy == nil {
+		omit = append(omit, "merged_by", "merged_by_type")
+	}
+	return ioError(s.db.Omit(omit...).Save(cachePR).Error)
+}
+
+// SetPullCommitCount records the number of commits of a cached PR.
+func (s *Store) SetPullCommitCount(repo string, number, count int) error {
+	return ioError(s.db.Model(&Pull{}).Where("repo = ? AND number = ?", repo, number).
+		Update("commit_count", count).Error)
+}
+
+// GetPullCommitCount returns the recorded number of commits of a cached PR,
+// or 0 when it is unknown.
+func (s *Store) GetPullCommitCount(repo string, number int) (int, error) {
+	var pulls []Pull
+	err := s.db.Select("commit_count").Where("repo = ? AND number = ?", repo, number).Limit(1).Find(&pulls).Error
+	if err != nil {
+		return 0, ioError(err)
+	}
+	if len(pulls) == 0 {
+		return 0, nil
+	}
+	return pulls[0].CommitCount, nil
+}
+
+// SetPullMissingPatches records whether the files cached for a PR lack
+// their patches.
+func (s *Store) SetPullMissingPatches(repo string, number int, missing bool) error {
+	return ioError(s.db.Model(&Pull{}).Where("repo = ? AND number = ?", repo, number).
+		Update("missing_patches", missing).Error)
+}
+
+// GetPullMissingPatches reports whether the files cached for a PR lack
+// their patches.
+func (s *Store) GetPullMissingPatches(repo string, number int) (bool, error) {
+	var count int64
+	err := s.db.Model(&Pull{}).Where("repo = ? AND number = ? AND missing_patches", repo, number).Count(&count).Error
+	return count > 0, ioError(err)
 }
 
-func (s *Store) SaveReview(review *models.Review) error {
+// GetPullsMissingPatches returns the numbers of the cached PRs of repo whose
+// files lack their patches.
+func (s *Store) GetPullsMissingPatches(repo string) ([]int, error) {
+	var numbers []int
+	err := s.db.Model(&Pull{}).Where("repo = ? AND missing_patches", repo).Order("number").Pluck("number", &numbers).Error
+	return numbers, ioError(err)
+}
+
+// SetPullMergedBy records who merged a cached PR.
+func (s *Store) SetPullMergedBy(repo string, number int, user models.User) error {
+	return ioError(s.db.Model(&Pull{}).Where("repo = ? AND number = ?", repo, number).
+		Updates(map[string]any{"merged_by": user.Login, "merged_by_type": user.Type}).Error)
+}
+
+func (s *Store) SaveReview(repo string, review *models.Review) error {
 	rawJSON, err := json.Marshal(review)
 	if err != nil {
 		return fmt.Errorf("marshaling raw JSON: %w", err)
 	}
 
 	cacheReview := &Review{
+		Repo:          repo,
 		ID:            review.ID,
 		PullNumber:    review.PullNumber,
 		Reviewer:      review.Reviewer.Login,
@@ -109,10 +217,10 @@ func (s *Store) SaveReview(review *models.Review) error {
 		RawJSON:       string(rawJSON),
 	}
 
-	return s.db.Save(cacheReview).Error
+	return ioError(s.db.Save(cacheReview).Error)
 }
 
-func (s *Store) SaveComment(comment *models.Comment) error {
+func (s *Store) SaveComment(repo string, comment *models.Comment) error {
 	rawJSON, err := json.Marshal(comment)
 	if err != nil {
 		return fmt.Errorf("marshaling raw JSON: %w", err)
@@ -121,6 +229,7 @@ func (s *Store) SaveComment(comment *models.Comment) error {
 	reactions, _ := json.Marshal(comment.Reactions)
 
 	cacheComment := &Comment{
+		Repo:        repo,
 		ID:          comment.ID,
 		PullNumber:  comment.PullNumber,
 		ReviewID:    comment.ReviewID,
@@ -139,16 +248,38 @@ func (s *Store) SaveComment(comment *models.Comment) error {
 		RawJSON:     string(rawJSON),
 	}
 
-	return s.db.Save(cacheComment).Error
+	return ioError(s.db.Save(cacheComment).Error)
+}
+
+func (s *Store) SaveReviewThread(repo string, thread *models.CommentThread) error {
+	rawJSON, err := json.Marshal(thread)
+	if err != nil {
+		return fmt.Errorf("marshaling raw JSON: %w", err)
+	}
+
+	cacheThread := &ReviewThread{
+		Repo:       repo,
+		PullNumber: thread.PullNumber,
+		RootID:     thread.RootID,
+		IsResolved: thread.IsResolved,
+		IsOutdated: thread.IsOutdated,
+		RawJSON:    string(rawJSON),
+	}
+	if thread.ResolvedBy != nil {
+		cacheThread.ResolvedBy = thread.ResolvedBy.Login
+	}
+
+	return ioError(s.db.Save(cacheThread).Error)
 }
 
-func (s *Store) SaveFile(file *models.File) error {
+func (s *Store) SaveFile(repo string, file *models.File) error {
 	rawJSON, err := json.Marshal(file)
 	if err != nil {
 		return fmt.Errorf("marshaling raw JSON: %w", err)
 	}
 
 	cacheFile := &File{
+		Repo:       repo,
 		PullNumber: file.PullNumber,
 		Filename:   file.Filename,
 		Status:     file.Status,
@@ -158,99 +289,456 @@ func (s *Store) SaveFile(file *models.File) error {
 		RawJSON:    string(rawJSON),
 	}
 
-	return s.db.Save(cacheFile).Error
+	return ioError(s.db.Save(cacheFile).Error)
 }
 
-func (s *Store) GetPullRequest(number int) (*models.PullRequest, error) {
+// ReplaceCommits stores commits as the commits of a PR, dropping cached
+// commits that a force push removed from it.
+func (s *Store) ReplaceCommits(repo string, prNumber int, commits []*models.Commit) error {
+	rows := make([]Commit, len(commits))
+	for i, commit := range commits {
+		rawJSON, err := json.Marshal(commit)
+		if err != nil {
+			return fmt.Errorf("marshaling raw JSON: %w", err)
+		}
+
+		author := commit.Author.Login
+		if author == "" {
+			author = commit.Author.Name
+		}
+		committer := commit.Committer.Login
+		if committer == "" {
+			committer = commit.Committer.Name
+		}
+
+		rows[i] = Commit{
+			Repo:        repo,
+			PullNumber:  prNumber,
+			SHA:         commit.SHA,
+			Position:    i,
+			Author:      author,
+			AuthorEmail: commit.Author.Email,
+			Committer:   committer,
+			AuthoredAt:  commit.AuthoredAt,
+			CommittedAt: commit.CommittedAt,
+			Verified:    commit.Verified,
+			RawJSON:     string(rawJSON),
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo = ? AND pull_number = ?", repo, prNumber).Delete(&Commit{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+	return ioError(err)
+}
+
+func (s *Store) SaveCheckRun(repo string, check *models.CheckRun) error {
+	cacheCheck, err := checkRow(repo, check)
+	if err != nil {
+		return err
+	}
+	return ioError(s.db.Save(cacheCheck).Error)
+}
+
+// ReplaceChecks stores checks as the CI results of the given kind for
+// commit sha of a PR, dropping those cached for it before.
+func (s *Store) ReplaceChecks(repo string, prNumber int, kind, sha string, checks []*models.CheckRun) error {
+	rows := make([]*CheckRun, len(checks))
+	for i, check := range checks {
+		row, err := checkRow(repo, check)
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo = ? AND pull_number = ? AND kind = ? AND head_sha = ?", repo, prNumber, kind, sha).
+			Delete(&CheckRun{}).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+	return ioError(err)
+}
+
+func checkRow(repo string, check *models.CheckRun) (*CheckRun, error) {
+	rawJSON, err := json.Marshal(check)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling raw JSON: %w", err)
+	}
+
+	return &CheckRun{
+		Repo:        repo,
+		PullNumber:  check.PullNumber,
+		Kind:        check.Kind,
+		ID:          check.ID,
+		HeadSHA:     check.HeadSHA,
+		Name:        check.Name,
+		Status:      check.Status,
+		Conclusion:  check.Conclusion,
+		App:         check.App,
+		StartedAt:   check.StartedAt,
+		CompletedAt: check.CompletedAt,
+		RawJSON:     string(rawJSON),
+	}, nil
+}
+
+func (s *Store) SaveTimelineEvent(repo string, event *models.TimelineEvent) error {
+	rawJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling raw JSON: %w", err)
+	}
+
+	cacheEvent := &TimelineEvent{
+		Repo:       repo,
+		ID:         event.ID,
+		PullNumber: event.PullNumber,
+		Event:      event.Event,
+		Actor:      event.Actor.Login,
+		CreatedAt:  event.CreatedAt,
+		RawJSON:    string(rawJSON),
+	}
+
+	return ioError(s.db.Save(cacheEvent).Error)
+}
+
+func (s *Store) GetPullRequest(repo string, number int) (*models.PullRequest, error) {
 	var pull Pull
-	if err := s.db.First(&pull, "number = ?", number).Error; err != nil {
-		return nil, err
+	if err := s.db.First(&pull, "repo = ? AND number = ?", repo, number).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		return nil, ioError(err)
 	}
 
-	var pr models.PullRequest
-	if err := json.Unmarshal([]byte(pull.RawJSON), &pr); err != nil {
-		return nil, fmt.Errorf("unmarshaling PR: %w", err)
+	return decodePull(&pull)
+}
+
+// GetPullUpdatedAt returns when a cached PR was last updated on GitHub, or
+// nil when the PR is not cached.
+func (s *Store) GetPullUpdatedAt(repo string, number int) (*time.Time, error) {
+	var pulls []Pull
+	err := s.db.Select("updated_at").Where("repo = ? AND number = ?", repo, number).Limit(1).Find(&pulls).Error
+	if err != nil {
+		return nil, ioError(err)
 	}
+	if len(pulls) == 0 {
+		return nil, nil
+	}
+	return &pulls[0].UpdatedAt, nil
+}
 
-	return &pr, nil
+// CountPullRequests returns the number of cached PRs for repo by state.
+func (s *Store) CountPullRequests(repo string) (total, open, closed int, err error) {
+	var counts []struct {
+		State string
+		Count int
+	}
+	err = s.db.Model(&Pull{}).Select("state, COUNT(*) AS count").
+		Where("repo = ?", repo).Group("state").Scan(&counts).Error
+	if err != nil {
+		return 0, 0, 0, ioError(err)
+	}
+
+	for _, c := range counts {
+		total += c.Count
+		switch c.State {
+		case "open":
+			open = c.Count
+		case "closed":
+			closed = c.Count
+		}
+	}
+	return total, open, closed, nil
 }
 
 func (s *Store) GetPullRequests(repo string, since time.Time) ([]*models.PullRequest, error) {
+	return s.QueryPullRequests(repo, Filter{UpdatedSince: since})
+}
+
+// QueryPullRequests returns the PRs of repo matching f, most recently
+// updated first.
+func (s *Store) QueryPullRequests(repo string, f Filter) ([]*models.PullRequest, error) {
 	var pulls []Pull
-	query := s.db.Order("updated_at DESC")
+	query := f.apply(s.db.Where("repo = ?", repo)).Order("updated_at DESC, number DESC")
 
-	if !since.IsZero() {
-		query = query.Where("updated_at >= ?", since)
+	if err := query.Find(&pulls).Error; err != nil {
+		return nil, ioError(err)
 	}
 
-	if err := query.Find(&pulls).Error; err != nil {
-		return nil, err
+	return decodePulls(pulls)
+}
+
+// PullRequests yields the PRs of repo matching f in the order of
+// QueryPullRequests, with their reviews, comments and files loaded. PRs are
+// read one page at a time, so memory use does not grow with the number of
+// PRs in the cache.
+func (s *Store) PullRequests(repo string, f Filter) iter.Seq2[*models.PullRequest, error] {
+	return func(yield func(*models.PullRequest, error) bool) {
+		var last *Pull
+		yielded := 0
+		for {
+			page := f
+			page.Limit = loadBatchSize
+			if f.Limit > 0 {
+				page.Limit = min(page.Limit, f.Limit-yielded)
+			}
+
+			// Continue after the last PR of the previous page
+			query := page.apply(s.db.Where("repo = ?", repo))
+			if last != nil {
+				query = query.Where("(updated_at < ? OR (updated_at = ? AND number < ?))", last.UpdatedAt, last.UpdatedAt, last.Number)
+			}
+
+			var pulls []Pull
+			if err := query.Order("updated_at DESC, number DESC").Find(&pulls).Error; err != nil {
+				yield(nil, ioError(err))
+				return
+			}
+			prs, err := decodePulls(pulls)
+			if err == nil {
+				err = s.LoadPullRequestDetails(repo, prs)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, pr := range prs {
+				if !yield(pr, nil) {
+					return
+				}
+			}
+			yielded += len(prs)
+
+			if len(pulls) < page.Limit || (f.Limit > 0 && yielded >= f.Limit) {
+				return
+			}
+			last = &pulls[len(pulls)-1]
+		}
 	}
+}
 
+func decodePulls(pulls []Pull) ([]*models.PullRequest, error) {
 	prs := make([]*models.PullRequest, len(pulls))
-	for i, pull := range pulls {
-		var pr models.PullRequest
-		if err := json.Unmarshal([]byte(pull.RawJSON), &pr); err != nil {
-			return nil, fmt.Errorf("unmarshaling PR %d: %w", pull.Number, err)
+	for i := range pulls {
+		pr, err := decodePull(&pulls[i])
+		if err != nil {
+			return nil, err
 		}
-		prs[i] = &pr
+		prs[i] = pr
 	}
-
 	return prs, nil
 }
 
-func (s *Store) GetReviews(prNumber int) ([]*models.Review, error) {
-	var reviews []Review
-	if err := s.db.Where("pull_number = ?", prNumber).Find(&reviews).Error; err != nil {
-		return nil, err
+// decodePull decodes the raw JSON of pull, with the columns the detail
+// fetch updates in place taking precedence.
+func decodePull(pull *Pull) (*models.PullRequest, error) {
+	var pr models.PullRequest
+	if err := json.Unmarshal([]byte(pull.RawJSON), &pr); err != nil {
+		return nil, fmt.Errorf("unmarshaling PR %d: %w", pull.Number, err)
 	}
 
-	result := make([]*models.Review, len(reviews))
-	for i, review := range reviews {
-		var r models.Review
-		if err := json.Unmarshal([]byte(review.RawJSON), &r); err != nil {
-			return nil, fmt.Errorf("unmarshaling review %d: %w", review.ID, err)
-		}
-		result[i] = &r
+	pr.Stats.Commits = pull.CommitCount
+	if pull.MergedBy != "" {
+		pr.MergedBy = &models.User{Login: pull.MergedBy, Type: pull.MergedByType}
 	}
+	return &pr, nil
+}
 
-	return result, nil
+// loadBatchSize bounds the PR numbers per query of the batch loaders,
+// keeping well below SQLite's limit on bound parameters.
+var loadBatchSize = 500
+
+func (s *Store) GetReviews(repo string, prNumber int) ([]*models.Review, error) {
+	reviews, err := s.GetReviewsByPull(repo, []int{prNumber})
+	return forPull(reviews, prNumber, err)
+}
+
+func (s *Store) GetComments(repo string, prNumber int) ([]*models.Comment, error) {
+	comments, err := s.GetCommentsByPull(repo, []int{prNumber})
+	return forPull(comments, prNumber, err)
 }
 
-func (s *Store) GetComments(prNumber int) ([]*models.Comment, error) {
-	var comments []Comment
-	if err := s.db.Where("pull_number = ?", prNumber).Order("created_at").Find(&comments).Error; err != nil {
+func (s *Store) GetFiles(repo string, prNumber int) ([]*models.File, error) {
+	files, err := s.GetFilesByPull(repo, []int{prNumber})
+	return forPull(files, prNumber, err)
+}
+
+// forPull returns the rows of one PR from a batch load, or an empty slice
+// when it has none.
+func forPull[T any](rows map[int][]*T, prNumber int, err error) ([]*T, error) {
+	if err != nil {
 		return nil, err
 	}
+	if rows[prNumber] == nil {
+		return []*T{}, nil
+	}
+	return rows[prNumber], nil
+}
+
+// pullRow is a row of PR details as loadByPull reads it.
+type pullRow struct {
+	PullNumber int
+	RawJSON    string
+	// Position is only set for tables ordered by it
+	Position int
+}
 
-	result := make([]*models.Comment, len(comments))
-	for i, comment := range comments {
-		var c models.Comment
-		if err := json.Unmarshal([]byte(comment.RawJSON), &c); err != nil {
-			return nil, fmt.Errorf("unmarshaling comment %d: %w", comment.ID, err)
+// loadByPull loads the rows of table for the given PRs, one query per batch
+// of PRs rather than one per PR, and decodes their raw JSON keyed by PR
+// number. Rows are ordered by order within each PR, and the order columns
+// are read along with them. fill restores what the raw JSON leaves out,
+// such as the PR number.
+func loadByPull[T any](s *Store, table, order, repo string, prNumbers []int, fill func(*T, *pullRow)) (map[int][]*T, error) {
+	result := make(map[int][]*T, len(prNumbers))
+	for batch := range slices.Chunk(prNumbers, loadBatchSize) {
+		var rows []pullRow
+		err := s.db.Table(table).Select("pull_number, raw_json, "+order).
+			Where("repo = ? AND pull_number IN ?", repo, batch).
+			Order("pull_number, " + order).Scan(&rows).Error
+		if err != nil {
+			return nil, ioError(err)
 		}
-		result[i] = &c
-	}
 
+		for i := range rows {
+			row := &rows[i]
+			item := new(T)
+			if err := json.Unmarshal([]byte(row.RawJSON), item); err != nil {
+				return nil, fmt.Errorf("unmarshaling %s of PR %d: %w", table, row.PullNumber, err)
+			}
+			fill(item, row)
+			result[row.PullNumber] = append(result[row.PullNumber], item)
+		}
+	}
 	return result, nil
 }
 
-func (s *Store) GetFiles(prNumber int) ([]*models.File, error) {
-	var files []File
-	if err := s.db.Where("pull_number = ?", prNumber).Find(&files).Error; err != nil {
-		return nil, err
+// GetReviewsByPull returns the reviews of the given PRs keyed by PR number.
+func (s *Store) GetReviewsByPull(repo string, prNumbers []int) (map[int][]*models.Review, error) {
+	return loadByPull(s, "reviews", "id", repo, prNumbers, func(r *models.Review, row *pullRow) {
+		r.PullNumber = row.PullNumber
+	})
+}
+
+// GetCommentsByPull returns the comments of the given PRs keyed by PR
+// number, oldest first.
+func (s *Store) GetCommentsByPull(repo string, prNumbers []int) (map[int][]*models.Comment, error) {
+	return loadByPull(s, "comments", "created_at", repo, prNumbers, func(c *models.Comment, row *pullRow) {
+		c.PullNumber = row.PullNumber
+	})
+}
+
+// GetFilesByPull returns the changed files of the given PRs keyed by PR
+// number.
+func (s *Store) GetFilesByPull(repo string, prNumbers []int) (map[int][]*models.File, error) {
+	return loadByPull(s, "files", "filename", repo, prNumbers, func(f *models.File, row *pullRow) {
+		f.PullNumber = row.PullNumber
+	})
+}
+
+// GetThreadsByPull returns the review thread states of the given PRs keyed
+// by PR number. Replies are not filled in.
+func (s *Store) GetThreadsByPull(repo string, prNumbers []int) (map[int][]*models.CommentThread, error) {
+	return loadByPull(s, "review_threads", "root_id", repo, prNumbers, func(t *models.CommentThread, row *pullRow) {
+		t.PullNumber = row.PullNumber
+	})
+}
+
+// GetChecksByPull returns the check runs and commit statuses of the given
+// PRs keyed by PR number, in the order they started.
+func (s *Store) GetChecksByPull(repo string, prNumbers []int) (map[int][]*models.CheckRun, error) {
+	return loadByPull(s, "ci_checks", "started_at, id", repo, prNumbers, func(c *models.CheckRun, row *pullRow) {
+		c.PullNumber = row.PullNumber
+	})
+}
+
+// GetTimelineByPull returns the timeline events of the given PRs keyed by
+// PR number, oldest first.
+func (s *Store) GetTimelineByPull(repo string, prNumbers []int) (map[int][]*models.TimelineEvent, error) {
+	return loadByPull(s, "timeline_events", "created_at, id", repo, prNumbers, func(e *models.TimelineEvent, row *pullRow) {
+		e.PullNumber = row.PullNumber
+	})
+}
+
+// GetCommitsByPull returns the commits of the given PRs keyed by PR number,
+// in the order GitHub lists them.
+func (s *Store) GetCommitsByPull(repo string, prNumbers []int) (map[int][]*models.Commit, error) {
+	return loadByPull(s, "commits", "position", repo, prNumbers, func(c *models.Commit, row *pullRow) {
+		c.PullNumber = row.PullNumber
+		c.Position = row.Position
+	})
+}
+
+// LoadPullRequestDetails fills in the reviews, comments, review threads,
+// files, commits, CI checks and timeline of prs with one query each per
+// batch of PRs.
+func (s *Store) LoadPullRequestDetails(repo string, prs []*models.PullRequest) error {
+	numbers := make([]int, len(prs))
+	for i, pr := range prs {
+		numbers[i] = pr.Number
 	}
 
-	result := make([]*models.File, len(files))
-	for i, file := range files {
-		var f models.File
-		if err := json.Unmarshal([]byte(file.RawJSON), &f); err != nil {
-			return nil, fmt.Errorf("unmarshaling file %s: %w", file.Filename, err)
-		}
-		result[i] = &f
+	reviews, err := s.GetReviewsByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading reviews: %w", err)
+	}
+	comments, err := s.GetCommentsByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading comments: %w", err)
+	}
+	files, err := s.GetFilesByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading files: %w", err)
+	}
+	commits, err := s.GetCommitsByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading commits: %w", err)
+	}
+	threads, err := s.GetThreadsByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading review threads: %w", err)
+	}
+	checks, err := s.GetChecksByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading CI checks: %w", err)
+	}
+	timeline, err := s.GetTimelineByPull(repo, numbers)
+	if err != nil {
+		return fmt.Errorf("loading timeline: %w", err)
 	}
 
-	return result, nil
+	for _, pr := range prs {
+		pr.Reviews = derefAll(reviews[pr.Number])
+		pr.Comments = derefAll(comments[pr.Number])
+		pr.Threads = derefAll(threads[pr.Number])
+		pr.Files = derefAll(files[pr.Number])
+		pr.Commits = derefAll(commits[pr.Number])
+		pr.Checks = derefAll(checks[pr.Number])
+		pr.Timeline = derefAll(timeline[pr.Number])
+	}
+	return nil
+}
+
+// derefAll copies the values of items into a slice, which is never nil so
+// that PRs without children still export empty lists.
+func derefAll[T any](items []*T) []T {
+	result := make([]T, len(items))
+	for i, item := range items {
+		result[i] = *item
+	}
+	return result
 }
 
 func (s *Store) GetSyncMetadata(repo string) (*models.SyncMetadata, error) {
@@ -259,7 +747,7 @@ func (s *Store) GetSyncMetadata(repo string) (*models.SyncMetadata, error) {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		return nil, err
+		return nil, ioError(err)
 	}
 
 	return &models.SyncMetadata{
@@ -269,6 +757,7 @@ func (s *Store) GetSyncMetadata(repo string) (*models.SyncMetadata, error) {
 		TotalPRs:     meta.TotalPRs,
 		OpenPRs:      meta.OpenPRs,
 		ClosedPRs:    meta.ClosedPRs,
+		Complete:     meta.Complete,
 	}, nil
 }
 
@@ -280,9 +769,89 @@ func (s *Store) SaveSyncMetadata(meta *models.SyncMetadata) error {
 		TotalPRs:     meta.TotalPRs,
 		OpenPRs:      meta.OpenPRs,
 		ClosedPRs:    meta.ClosedPRs,
+		Complete:     meta.Complete,
 	}
 
-	return s.db.Save(cacheMeta).Error
+	return ioError(s.db.Save(cacheMeta).Error)
+}
+
+// GetFetchCheckpoint returns the checkpoint of an interrupted sync of repo,
+// or nil when there is none.
+func (s *Store) GetFetchCheckpoint(repo string) (*models.FetchCheckpoint, error) {
+	var cp FetchCheckpoint
+	if err := s.db.First(&cp, "repo = ?", repo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, ioError(err)
+	}
+
+	var pending []int
+	if err := json.Unmarshal([]byte(cp.Pending), &pending); err != nil {
+		return nil, fmt.Errorf("unmarshaling pending PRs: %w", err)
+	}
+
+	return &models.FetchCheckpoint{
+		Repo:            cp.Repo,
+		Page:            cp.Page,
+		Cursor:          cp.Cursor,
+		Listed:          cp.Listed,
+		LastCompletedPR: cp.LastCompletedPR,
+		Pending:         pending,
+		Limit:           cp.Limit,
+		Refetch:         cp.Refetch,
+		Cutoff:          cp.Cutoff,
+		SyncCutoff:      cp.SyncCutoff,
+		Unbounded:       cp.Unbounded,
+		StartedAt:       cp.StartedAt,
+		UpdatedAt:       cp.UpdatedAt,
+	}, nil
+}
+
+func (s *Store) SaveFetchCheckpoint(cp *models.FetchCheckpoint) error {
+	pending, err := json.Marshal(cp.Pending)
+	if err != nil {
+		return fmt.Errorf("marshaling pending PRs: %w", err)
+	}
+
+	cacheCP := &FetchCheckpoint{
+		Repo:            cp.Repo,
+		Page:            cp.Page,
+		Cursor:          cp.Cursor,
+		Listed:          cp.Listed,
+		LastCompletedPR: cp.LastCompletedPR,
+		Pending:         string(pending),
+		Limit:           cp.Limit,
+		Refetch:         cp.Refetch,
+		Cutoff:          cp.Cutoff,
+		SyncCutoff:      cp.SyncCutoff,
+		Unbounded:       cp.Unbounded,
+		StartedAt:       cp.StartedAt,
+		UpdatedAt:       cp.UpdatedAt,
+	}
+
+	return ioError(s.db.Save(cacheCP).Error)
+}
+
+func (s *Store) DeleteFetchCheckpoint(repo string) error {
+	return ioError(s.db.Where("repo = ?", repo).Delete(&FetchCheckpoint{}).Error)
+}
+
+// GetHTTPValidator returns the validators stored for url and credential, or
+// nil when the URL has not been fetched with the credential before.
+func (s *Store) GetHTTPValidator(url, credential string) (*HTTPValidator, error) {
+	var v HTTPValidator
+	if err := s.db.First(&v, "url = ? AND credential = ?", url, credential).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, ioError(err)
+	}
+	return &v, nil
+}
+
+func (s *Store) SaveHTTPValidator(v *HTTPValidator) error {
+	return ioError(s.db.Save(v).Error)
 }
 
 func (s *Store) GetStats(repo string) (map[string]interface{}, error) {
@@ -290,31 +859,51 @@ func (s *Store) GetStats(repo string) (map[string]interface{}, error) {
 
 	// Count PRs
 	var prCount int64
-	s.db.Model(&Pull{}).Count(&prCount)
+	s.db.Model(&Pull{}).Where("repo = ?", repo).Count(&prCount)
 	stats["total_prs"] = prCount
 
 	// Count by state
 	var openCount, closedCount int64
-	s.db.Model(&Pull{}).Where("state = ?", "open").Count(&openCount)
-	s.db.Model(&Pull{}).Where("state = ?", "closed").Count(&closedCount)
+	s.db.Model(&Pull{}).Where("repo = ? AND state = ?", repo, "open").Count(&openCount)
+	s.db.Model(&Pull{}).Where("repo = ? AND state = ?", repo, "closed").Count(&closedCount)
 	stats["open_prs"] = openCount
 	stats["closed_prs"] = closedCount
 
 	// Count reviews
 	var reviewCount int64
-	s.db.Model(&Review{}).Count(&reviewCount)
+	s.db.Model(&Review{}).Where("repo = ?", repo).Count(&reviewCount)
 	stats["total_reviews"] = reviewCount
 
 	// Count comments
 	var commentCount int64
-	s.db.Model(&Comment{}).Count(&commentCount)
+	s.db.Model(&Comment{}).Where("repo = ?", repo).Count(&commentCount)
 	stats["total_comments"] = commentCount
 
 	// Count files
 	var fileCount int64
-	s.db.Model(&File{}).Count(&fileCount)
+	s.db.Model(&File{}).Where("repo = ?", repo).Count(&fileCount)
 	stats["total_files"] = fileCount
 
+	// Count commits
+	var commitCount int64
+	s.db.Model(&Commit{}).Where("repo = ?", repo).Count(&commitCount)
+	stats["total_commits"] = commitCount
+
+	// Count review threads
+	var threadCount int64
+	s.db.Model(&ReviewThread{}).Where("repo = ?", repo).Count(&threadCount)
+	stats["total_threads"] = threadCount
+
+	// Count CI checks
+	var checkCount int64
+	s.db.Model(&CheckRun{}).Where("repo = ?", repo).Count(&checkCount)
+	stats["total_checks"] = checkCount
+
+	// Count timeline events
+	var eventCount int64
+	s.db.Model(&TimelineEvent{}).Where("repo = ?", repo).Count(&eventCount)
+	stats["total_timeline_events"] = eventCount
+
 	// Get sync metadata
 	meta, _ := s.GetSyncMetadata(repo)
 	if meta != nil {
@@ -324,28 +913,47 @@ func (s *Store) GetStats(repo string) (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// Clear removes cached data for repo, or for every repository when repo is empty.
 func (s *Store) Clear(repo string) error {
-	// If repo is specified, only clear data for that repo
-	// For now, we'll clear all data since we don't track repo in all tables
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		tables := []string{"pulls", "reviews", "comments", "files"}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tables := []string{"pulls", "reviews", "comments", "review_threads", "files", "commits", "ci_checks", "timeline_events", "sync_metadata", "fetch_checkpoints", "http_validators"}
 		for _, table := range tables {
-			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+			var err error
+			if repo != "" {
+				err = tx.Exec("DELETE FROM "+table+" WHERE repo = ?", repo).Error
+			} else {
+				err = tx.Exec("DELETE FROM " + table).Error
+			}
+			if err != nil {
 				return err
 			}
 		}
-
-		if repo != "" {
-			return tx.Where("repo = ?", repo).Delete(&SyncMetadata{}).Error
-		}
-		return tx.Exec("DELETE FROM sync_metadata").Error
+		return nil
 	})
+	return ioError(err)
+}
+
+// Repositories returns the repositories with cached data, sorted by name.
+func (s *Store) Repositories() ([]string, error) {
+	var repos []string
+	err := s.db.Raw("SELECT repo FROM pulls UNION SELECT repo FROM sync_metadata UNION SELECT repo FROM fetch_checkpoints ORDER BY repo").
+		Scan(&repos).Error
+	if err != nil {
+		return nil, ioError(err)
+	}
+	return repos, nil
+}
+
+// Vacuum rebuilds the database file to return the space freed by deleted
+// rows to the file system.
+func (s *Store) Vacuum() error {
+	return ioError(s.db.Exec("VACUUM").Error)
 }
 
 func (s *Store) Close() error {
 	sqlDB, err := s.db.DB()
 	if err != nil {
-		return err
+		return ioError(err)
 	}
-	return sqlDB.Close()
+	return ioError(sqlDB.Close())
 }
@@ -0,0 +1,591 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := NewStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestStoreScopesDataByRepository(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, repo := range []string{"golang/go", "microsoft/vscode"} {
+		pr := &models.PullRequest{ID: int64(len(repo)), Number: 1234, Title: repo, State: "open", UpdatedAt: time.Now()}
+		if err := store.SavePullRequest(repo, pr); err != nil {
+			t.Fatalf("SavePullRequest(%s) error = %v", repo, err)
+		}
+		if err := store.SaveReview(repo, &models.Review{ID: 1, PullNumber: 1234, State: repo}); err != nil {
+			t.Fatalf("SaveReview(%s) error = %v", repo, err)
+		}
+		if err := store.SaveFile(repo, &models.File{PullNumber: 1234, Filename: "main.go", Status: repo}); err != nil {
+			t.Fatalf("SaveFile(%s) error = %v", repo, err)
+		}
+	}
+
+	pr, err := store.GetPullRequest("golang/go", 1234)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if pr.Title != "golang/go" {
+		t.Errorf("GetPullRequest() title = %q, want %q", pr.Title, "golang/go")
+	}
+
+	prs, err := store.GetPullRequests("microsoft/vscode", time.Time{})
+	if err != nil {
+		t.Fatalf("GetPullRequests() error = %v", err)
+	}
+	if len(prs) != 1 || prs[0].Title != "microsoft/vscode" {
+		t.Errorf("GetPullRequests() = %v, want only the microsoft/vscode PR", prs)
+	}
+
+	reviews, err := store.GetReviews("golang/go", 1234)
+	if err != nil {
+		t.Fatalf("GetReviews() error = %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].State != "golang/go" {
+		t.Errorf("GetReviews() = %v, want only the golang/go review", reviews)
+	}
+
+	if err := store.Clear("golang/go"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, err := store.GetPullRequest("golang/go", 1234); err == nil {
+		t.Error("GetPullRequest() after Clear() returned no error")
+	}
+	files, err := store.GetFiles("microsoft/vscode", 1234)
+	if err != nil {
+		t.Fatalf("GetFiles() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("GetFiles() returned %d files after clearing another repo, want 1", len(files))
+	}
+	if reviews, err := store.GetReviews("golang/go", 1234); err != nil || reviews == nil || len(reviews) != 0 {
+		t.Errorf("GetReviews() after Clear() = %v, %v; want an empty slice", reviews, err)
+	}
+}
+
+func TestNewStoreMigratesLegacyCache(t *testing.T) {
+	type legacyPull struct {
+		ID                 int64 `gorm:"primaryKey"`
+		Number             int   `gorm:"uniqueIndex"`
+		Title              string
+		State              string
+		Author             string
+		AuthorType         string
+		AuthorIsBot        bool
+		Assignees          string
+		RequestedReviewers string
+		Labels             string
+		CreatedAt          time.Time
+		UpdatedAt          time.Time
+		MergedAt           *time.Time
+		LastFetchedAt      time.Time
+		RawJSON            string
+	}
+	type legacySyncMetadata struct {
+		Repo       string `gorm:"primaryKey"`
+		LastSyncAt time.Time
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("opening legacy database: %v", err)
+	}
+	if err := db.Table("pulls").AutoMigrate(&legacyPull{}); err != nil {
+		t.Fatalf("creating legacy pulls table: %v", err)
+	}
+	if err := db.Table("sync_metadata").AutoMigrate(&legacySyncMetadata{}); err != nil {
+		t.Fatalf("creating sync metadata table: %v", err)
+	}
+	db.Table("pulls").Create(&legacyPull{ID: 1, Number: 42, Title: "legacy", RawJSON: `{"number":42,"title":"legacy"}`})
+	db.Table("sync_metadata").Create(&legacySyncMetadata{Repo: "golang/go"})
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore() on legacy cache error = %v", err)
+	}
+	defer store.Close()
+
+	pr, err := store.GetPullRequest("golang/go", 42)
+	if err != nil {
+		t.Fatalf("GetPullRequest() after migration error = %v", err)
+	}
+	if pr.Title != "legacy" {
+		t.Errorf("migrated PR title = %q, want %q", pr.Title, "legacy")
+	}
+}
+
+func TestNewStoreRefusesNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		t.Fatalf("LatestVersion() error = %v", err)
+	}
+	if err := store.db.Create(&SchemaVersion{Version: latest + 1, Name: "future"}).Error; err != nil {
+		t.Fatalf("recording future version: %v", err)
+	}
+	_ = store.Close()
+
+	plan, err := PlanMigrations(dbPath)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("PlanMigrations() = %v, %v; want ErrSchemaTooNew", plan, err)
+	}
+	if _, err := NewStore(dbPath); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("NewStore() error = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestPlanMigrationsDoesNotWrite(t *testing.T) {
+	latest, err := LatestVersion()
+	if err != nil {
+		t.Fatalf("LatestVersion() error = %v", err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	plan, err := PlanMigrations(dbPath)
+	if err != nil {
+		t.Fatalf("PlanMigrations() on a missing cache error = %v", err)
+	}
+	if plan.Current != 0 || plan.Latest != latest || len(plan.Pending) != latest {
+		t.Errorf("PlanMigrations() on a missing cache = %+v, want every migration pending", plan)
+	}
+	if _, err := os.Stat(dbPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("PlanMigrations() created %s: %v", dbPath, err)
+	}
+
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	_ = store.Close()
+	before, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("stat cache: %v", err)
+	}
+
+	plan, err = PlanMigrations(dbPath)
+	if err != nil {
+		t.Fatalf("PlanMigrations() error = %v", err)
+	}
+	if plan.Current != latest || len(plan.Pending) != 0 {
+		t.Errorf("PlanMigrations() = %+v, want the cache at version %d", plan, latest)
+	}
+	if after, err := os.Stat(dbPath); err != nil || !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		t.Errorf("PlanMigrations() modified the cache: %v", err)
+	}
+}
+
+func TestClearRemovesOnlyTheRepository(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, repo := range []string{"golang/go", "microsoft/vscode"} {
+		if err := store.SavePullRequest(repo, &models.PullRequest{Number: 1, State: "open", UpdatedAt: time.Now()}); err != nil {
+			t.Fatalf("SavePullRequest(%s) error = %v", repo, err)
+		}
+	}
+	if err := store.SaveFetchCheckpoint(&models.FetchCheckpoint{Repo: "kubernetes/kubernetes", StartedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveFetchCheckpoint() error = %v", err)
+	}
+
+	repos, err := store.Repositories()
+	if err != nil {
+		t.Fatalf("Repositories() error = %v", err)
+	}
+	if want := []string{"golang/go", "kubernetes/kubernetes", "microsoft/vscode"}; !slices.Equal(repos, want) {
+		t.Errorf("Repositories() = %v, want %v", repos, want)
+	}
+
+	if err := store.Clear("golang/go"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if err := store.Vacuum(); err != nil {
+		t.Fatalf("Vacuum() error = %v", err)
+	}
+
+	repos, err = store.Repositories()
+	if err != nil {
+		t.Fatalf("Repositories() error = %v", err)
+	}
+	if want := []string{"kubernetes/kubernetes", "microsoft/vscode"}; !slices.Equal(repos, want) {
+		t.Errorf("Repositories() after Clear = %v, want %v", repos, want)
+	}
+}
+
+func TestQueryPullRequestsFilters(t *testing.T) {
+	store := newTestStore(t)
+	const repo = "golang/go"
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	merged := day(9)
+
+	pulls := []*models.PullRequest{
+		{Number: 1, State: "open", Author: models.User{Login: "Alice"}, BaseRef: "main",
+			Labels: []models.Label{{Name: "bug"}}, CreatedAt: day(1), UpdatedAt: day(10)},
+		{Number: 2, State: "closed", Author: models.User{Login: "bob"}, BaseRef: "release-1.0",
+			Labels: []models.Label{{Name: "Docs"}, {Name: "bug"}}, CreatedAt: day(3), UpdatedAt: day(9), MergedAt: &merged},
+		{Number: 3, State: "closed", Author: models.User{Login: "dependabot[bot]"}, BaseRef: "main",
+			CreatedAt: day(5), UpdatedAt: day(8)},
+		{Number: 4, State: "open", Author: models.User{Login: "carol"}, BaseRef: "main",
+			CreatedAt: day(7), UpdatedAt: day(7)},
+	}
+	for _, pr := range pulls {
+		if err := store.SavePullRequest(repo, pr); err != nil {
+			t.Fatalf("SavePullRequest(%d) error = %v", pr.Number, err)
+		}
+	}
+	// Another repository's data must never match
+	if err := store.SavePullRequest("other/repo", &models.PullRequest{Number: 5, State: "open", UpdatedAt: day(11)}); err != nil {
+		t.Fatalf("SavePullRequest() error = %v", err)
+	}
+	if err := store.SaveReview(repo, &models.Review{ID: 1, PullNumber: 2, Reviewer: models.User{Login: "Carol"}}); err != nil {
+		t.Fatalf("SaveReview() error = %v", err)
+	}
+	for _, file := range []*models.File{
+		{PullNumber: 1, Filename: "src/net/http/server.go"},
+		{PullNumber: 2, Filename: "doc/go1.22.html"},
+		{PullNumber: 4, Filename: "src/cmd/go/main.go"},
+	} {
+		if err := store.SaveFile(repo, file); err != nil {
+			t.Fatalf("SaveFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{"no filter", Filter{}, []int{1, 2, 3, 4}},
+		{"number", Filter{Number: 3}, []int{3}},
+		{"state", Filter{State: "closed"}, []int{2, 3}},
+		{"merged", Filter{MergedOnly: true}, []int{2}},
+		{"authors ignore case", Filter{Authors: []string{"alice", "CAROL"}}, []int{1, 4}},
+		{"reviewer", Filter{Reviewers: []string{"carol"}}, []int{2}},
+		{"label", Filter{Labels: []string{"bug"}}, []int{1, 2}},
+		{"labels ignore case", Filter{Labels: []string{"docs", "WONTFIX"}}, []int{2}},
+		{"path glob", Filter{Paths: []string{"src/**/*.go"}}, []int{1, 4}},
+		{"path glob within a directory", Filter{Paths: []string{"src/*.go"}}, nil},
+		{"any path", Filter{Paths: []string{"doc/*", "src/net"}}, []int{1, 2}},
+		{"base", Filter{Base: "main"}, []int{1, 3, 4}},
+		{"exclude bots", Filter{ExcludeBots: true}, []int{1, 2, 4}},
+		{"updated since", Filter{UpdatedSince: day(9)}, []int{1, 2}},
+		{"created range", Filter{CreatedSince: day(3), CreatedUntil: day(7)}, []int{2, 3}},
+		{"combined", Filter{Base: "main", ExcludeBots: true, State: "open"}, []int{1, 4}},
+		{"limit keeps most recently updated", Filter{Limit: 2}, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs, err := store.QueryPullRequests(repo, tt.filter)
+			if err != nil {
+				t.Fatalf("QueryPullRequests() error = %v", err)
+			}
+			var got []int
+			for _, pr := range prs {
+				got = append(got, pr.Number)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("QueryPullRequests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSavePullRequestKeepsDetailColumns(t *testing.T) {
+	store := newTestStore(t)
+	const repo = "golang/go"
+
+	mergedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	listed := &models.PullRequest{Number: 1, State: "closed", MergedAt: &mergedAt, UpdatedAt: mergedAt}
+	if err := store.SavePullRequest(repo, listed); err != nil {
+		t.Fatalf("SavePullRequest() error = %v", err)
+	}
+	if err := store.SetPullCommitCount(repo, 1, 3); err != nil {
+		t.Fatalf("SetPullCommitCount() error = %v", err)
+	}
+	if err := store.SetPullMergedBy(repo, 1, models.User{Login: "dave", Type: "User"}); err != nil {
+		t.Fatalf("SetPullMergedBy() error = %v", err)
+	}
+
+	// Listing the PR again must not drop what the detail fetch recorded
+	listed.Title = "Retitled"
+	if err := store.SavePullRequest(repo, listed); err != nil {
+		t.Fatalf("SavePullRequest() error = %v", err)
+	}
+
+	pr, err := store.GetPullRequest(repo, 1)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if pr.Title != "Retitled" || pr.Stats.Commits != 3 || pr.MergedBy == nil || *pr.MergedBy != (models.User{Login: "dave", Type: "User"}) {
+		t.Errorf("GetPullRequest() = %+v, want the new title with 3 commits merged by dave", pr)
+	}
+	if count, err := store.GetPullCommitCount(repo, 1); err != nil || count != 3 {
+		t.Errorf("GetPullCommitCount() = %d, %v, want 3", count, err)
+	}
+}
+
+func TestReplaceCommitsDropsForcePushedCommits(t *testing.T) {
+	store := newTestStore(t)
+	const repo = "golang/go"
+
+	commits := func(shas ...string) []*models.Commit {
+		var result []*models.Commit
+		for _, sha := range shas {
+			result = append(result, &models.Commit{SHA: sha, Author: models.CommitIdentity{Name: "Alice"}})
+		}
+		return result
+	}
+	if err := store.ReplaceCommits(repo, 1, commits("a1", "b2", "c3")); err != nil {
+		t.Fatalf("ReplaceCommits() error = %v", err)
+	}
+	if err := store.ReplaceCommits(repo, 2, commits("a1")); err != nil {
+		t.Fatalf("ReplaceCommits() error = %v", err)
+	}
+	// A force push rewrote the history of PR 1
+	if err := store.ReplaceCommits(repo, 1, commits("d4", "a1")); err != nil {
+		t.Fatalf("ReplaceCommits() error = %v", err)
+	}
+
+	got, err := store.GetCommitsByPull(repo, []int{1, 2})
+	if err != nil {
+		t.Fatalf("GetCommitsByPull() error = %v", err)
+	}
+	shas := func(commits []*models.Commit) []string {
+		var result []string
+		for _, commit := range commits {
+			result = append(result, commit.SHA)
+		}
+		return result
+	}
+	if want := []string{"d4", "a1"}; !slices.Equal(shas(got[1]), want) {
+		t.Errorf("commits of PR 1 = %v, want %v", shas(got[1]), want)
+	}
+	if want := []string{"a1"}; !slices.Equal(shas(got[2]), want) {
+		t.Errorf("commits of PR 2 = %v, want %v", shas(got[2]), want)
+	}
+	if c := got[1]; len(c) != 2 || c[0].Position != 0 || c[1].Position != 1 {
+		t.Errorf("commits of PR 1 = %+v, want their stored positions", c)
+	}
+}
+
+// seedLargeCache writes prs PRs of golang/go, each with a few reviews,
+// comments and files, in bulk rather than through the Save methods.
+func seedLargeCache(tb testing.TB, store *Store, prs int) {
+	tb.Helper()
+	const repo = "golang/go"
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	marshal := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			tb.Fatalf("json.Marshal() error = %v", err)
+		}
+		return string(data)
+	}
+
+	var pulls []Pull
+	var reviews []Review
+	var comments []Comment
+	var files []File
+	for n := 1; n <= prs; n++ {
+		at := created.Add(time.Duration(n) * time.Minute)
+		pulls = append(pulls, Pull{Repo: repo, Number: n, State: "closed", CreatedAt: at, UpdatedAt: at,
+			RawJSON: marshal(models.PullRequest{Number: n, State: "closed", CreatedAt: at, UpdatedAt: at})})
+		for i := range 3 {
+			review := models.Review{ID: int64(n*10 + i), PullNumber: n, State: "APPROVED", SubmittedAt: at}
+			reviews = append(reviews, Review{Repo: repo, ID: review.ID, PullNumber: n, RawJSON: marshal(review)})
+		}
+		for i := range 5 {
+			comment := models.Comment{ID: int64(n*10 + i), PullNumber: n, Body: "looks good", CreatedAt: at.Add(time.Duration(i) * time.Second)}
+			comments = append(comments, Comment{Repo: repo, ID: comment.ID, PullNumber: n, CreatedAt: comment.CreatedAt, RawJSON: marshal(comment)})
+		}
+		for i := range 5 {
+			file := models.File{PullNumber: n, Filename: fmt.Sprintf("src/pkg%d/file.go", i), Additions: 10, Patch: "@@ -1 +1 @@"}
+			files = append(files, File{Repo: repo, PullNumber: n, Filename: file.Filename, RawJSON: marshal(file)})
+		}
+	}
+
+	err := store.db.Transaction(func(tx *gorm.DB) error {
+		for _, rows := range []any{pulls, reviews, comments, files} {
+			if err := tx.CreateInBatches(rows, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("seeding cache: %v", err)
+	}
+}
+
+func TestLoadPullRequestDetailsAcrossBatches(t *testing.T) {
+	store := newTestStore(t)
+	seedLargeCache(t, store, 25)
+	defer func(size int) { loadBatchSize = size }(loadBatchSize)
+	loadBatchSize = 7
+
+	prs, err := store.GetPullRequests("golang/go", time.Time{})
+	if err != nil {
+		t.Fatalf("GetPullRequests() error = %v", err)
+	}
+	// A PR without children still gets empty lists
+	prs = append(prs, &models.PullRequest{Number: 999})
+	if err := store.LoadPullRequestDetails("golang/go", prs); err != nil {
+		t.Fatalf("LoadPullRequestDetails() error = %v", err)
+	}
+
+	for _, pr := range prs[:25] {
+		if len(pr.Reviews) != 3 || len(pr.Comments) != 5 || len(pr.Files) != 5 {
+			t.Fatalf("PR #%d has %d reviews, %d comments and %d files, want 3, 5 and 5",
+				pr.Number, len(pr.Reviews), len(pr.Comments), len(pr.Files))
+		}
+		for _, review := range pr.Reviews {
+			if review.PullNumber != pr.Number {
+				t.Errorf("PR #%d got review %d of PR #%d", pr.Number, review.ID, review.PullNumber)
+			}
+		}
+		if !slices.IsSortedFunc(pr.Comments, func(a, b models.Comment) int { return a.CreatedAt.Compare(b.CreatedAt) }) {
+			t.Errorf("comments of PR #%d are not oldest first", pr.Number)
+		}
+
+		files, err := store.GetFiles("golang/go", pr.Number)
+		if err != nil {
+			t.Fatalf("GetFiles() error = %v", err)
+		}
+		if len(files) != len(pr.Files) || files[0].Filename != pr.Files[0].Filename {
+			t.Errorf("GetFiles(%d) and LoadPullRequestDetails disagree", pr.Number)
+		}
+	}
+
+	empty := prs[25]
+	if empty.Reviews == nil || empty.Comments == nil || empty.Files == nil || len(empty.Files) != 0 {
+		t.Errorf("PR without children = %+v, want empty lists", empty)
+	}
+}
+
+func TestPullRequestsYieldsPagesInQueryOrder(t *testing.T) {
+	store := newTestStore(t)
+	seedLargeCache(t, store, 25)
+	defer func(size int) { loadBatchSize = size }(loadBatchSize)
+	loadBatchSize = 4
+
+	// PRs updated at the same time must not be skipped or repeated at page
+	// boundaries
+	tie := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.db.Model(&Pull{}).Where("number <= 10").Update("updated_at", tie).Error; err != nil {
+		t.Fatalf("updating PRs: %v", err)
+	}
+
+	numbers := func(f Filter) []int {
+		var got []int
+		for pr, err := range store.PullRequests("golang/go", f) {
+			if err != nil {
+				t.Fatalf("PullRequests() error = %v", err)
+			}
+			if len(pr.Reviews) != 3 {
+				t.Errorf("PR #%d has %d reviews, want 3", pr.Number, len(pr.Reviews))
+			}
+			got = append(got, pr.Number)
+		}
+		return got
+	}
+
+	for _, f := range []Filter{{}, {Limit: 9}, {Limit: 8}, {Limit: 100}, {UpdatedSince: tie}} {
+		prs, err := store.QueryPullRequests("golang/go", f)
+		if err != nil {
+			t.Fatalf("QueryPullRequests() error = %v", err)
+		}
+		var want []int
+		for _, pr := range prs {
+			want = append(want, pr.Number)
+		}
+		if got := numbers(f); !slices.Equal(got, want) {
+			t.Errorf("PullRequests(%+v) = %v, want %v", f, got, want)
+		}
+	}
+
+	// Stopping early ends the iteration
+	read := 0
+	for range store.PullRequests("golang/go", Filter{}) {
+		read++
+		if read == 5 {
+			break
+		}
+	}
+	if read != 5 {
+		t.Errorf("read %d PRs, want 5", read)
+	}
+}
+
+// benchmarkLoad loads the children of every PR in a cache of 5,000 PRs.
+func benchmarkLoad(b *testing.B, load func(*Store, []*models.PullRequest) error) {
+	store, err := NewStore(filepath.Join(b.TempDir(), "cache.db"))
+	if err != nil {
+		b.Fatalf("NewStore() error = %v", err)
+	}
+	b.Cleanup(func() { _ = store.Close() })
+	seedLargeCache(b, store, 5000)
+
+	prs, err := store.GetPullRequests("golang/go", time.Time{})
+	if err != nil {
+		b.Fatalf("GetPullRequests() error = %v", err)
+	}
+
+	for b.Loop() {
+		if err := load(store, prs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadDetailsPerPull(b *testing.B) {
+	benchmarkLoad(b, func(store *Store, prs []*models.PullRequest) error {
+		for _, pr := range prs {
+			if _, err := store.GetReviews("golang/go", pr.Number); err != nil {
+				return err
+			}
+			if _, err := store.GetComments("golang/go", pr.Number); err != nil {
+				return err
+			}
+			if _, err := store.GetFiles("golang/go", pr.Number); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkLoadDetailsBatched(b *testing.B) {
+	benchmarkLoad(b, func(store *Store, prs []*models.PullRequest) error {
+		return store.LoadPullRequestDetails("golang/go", prs)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -14,11 +16,73 @@ type Config struct {
 	Cache  CacheConfig  `yaml:"cache"`
 	Export ExportConfig `yaml:"export"`
 	Fetch  FetchConfig  `yaml:"fetch"`
+
+	// Profiles are named sets of settings layered over the ones above, and
+	// Repos settings that apply to a single owner/repo only
+	Profiles map[string]yaml.Node `yaml:"profiles,omitempty"`
+	Repos    map[string]yaml.Node `yaml:"repos,omitempty"`
+}
+
+// LoadOptions selects the layers of the config file that apply.
+type LoadOptions struct {
+	// Profile names an entry under profiles; empty uses none
+	Profile string
+	// Repo selects the overrides under repos for an owner/repo
+	Repo string
 }
 
 type GitHubConfig struct {
-	Token  string `yaml:"token"`
-	APIURL string `yaml:"api_url"`
+	Token string `yaml:"token"`
+	// Tokens are additional tokens to rotate through when one runs low on
+	// its rate limit budget
+	Tokens []string `yaml:"tokens,omitempty"`
+	APIURL string   `yaml:"api_url"`
+	// UploadURL is the GitHub Enterprise Server upload endpoint; it is
+	// derived from APIURL when empty
+	UploadURL string          `yaml:"upload_url"`
+	App       GitHubAppConfig `yaml:"app"`
+	// Proxy is an http, https or socks5 proxy URL. When empty, the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply
+	Proxy string    `yaml:"proxy"`
+	TLS   TLSConfig `yaml:"tls"`
+}
+
+// AllTokens returns Token followed by Tokens, without blanks or duplicates.
+func (g GitHubConfig) AllTokens() []string {
+	var tokens []string
+	for _, token := range append([]string{g.Token}, g.Tokens...) {
+		token = strings.TrimSpace(token)
+		if token != "" && !slices.Contains(tokens, token) {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// GitHubAppConfig authenticates as a GitHub App installation instead of
+// with a personal token.
+type GitHubAppConfig struct {
+	AppID          int64  `yaml:"app_id"`
+	InstallationID int64  `yaml:"installation_id"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+}
+
+// Enabled reports whether GitHub App authentication is configured.
+func (a GitHubAppConfig) Enabled() bool {
+	return a.AppID != 0
+}
+
+// TLSConfig adjusts how the GitHub server is verified, for GitHub
+// Enterprise Server instances with an internal CA or mutual TLS.
+type TLSConfig struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile are a PEM client certificate and its key
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// InsecureSkipVerify disables certificate verification; only use it
+	// for test instances
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
 }
 
 type CacheConfig struct {
@@ -32,8 +96,17 @@ type ExportConfig struct {
 }
 
 type FetchConfig struct {
-	BatchSize       int `yaml:"batch_size"`
-	RateLimitBuffer int `yaml:"rate_limit_buffer"`
+	BatchSize       int    `yaml:"batch_size"`
+	RateLimitBuffer int    `yaml:"rate_limit_buffer"`
+	Concurrency     int    `yaml:"concurrency"`
+	API             string `yaml:"api"`
+	// MaxRetries bounds the retries of a request failing with a transient
+	// error, and RetryMaxElapsed the time spent retrying it
+	MaxRetries      int           `yaml:"max_retries"`
+	RetryMaxElapsed time.Duration `yaml:"retry_max_elapsed"`
+	// Checks fetches CI check runs and commit statuses, which needs the
+	// Checks and Commit statuses permissions on fine-grained tokens
+	Checks bool `yaml:"checks"`
 }
 
 func DefaultConfig() *Config {
@@ -41,7 +114,6 @@ func DefaultConfig() *Config {
 
 	return &Config{
 		GitHub: GitHubConfig{
-			Token:  os.Getenv("GITHUB_TOKEN"),
 			APIURL: "https://api.github.com",
 		},
 		Cache: CacheConfig{
@@ -55,18 +127,33 @@ func DefaultConfig() *Config {
 		Fetch: FetchConfig{
 			BatchSize:       100,
 			RateLimitBuffer: 100,
+			Concurrency:     4,
+			API:             "rest",
+			MaxRetries:      5,
+			RetryMaxElapsed: 2 * time.Minute,
+			Checks:          true,
 		},
 	}
 }
 
-func Load(path string) (*Config, error) {
+// DefaultPath returns the config file to use when none is given:
+// $PR_ANALYZER_CONFIG, or config.yaml in the default cache location.
+func DefaultPath() string {
+	if path := os.Getenv("PR_ANALYZER_CONFIG"); path != "" {
+		return path
+	}
+	return DefaultConfig().ConfigPath()
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the config file at path, the profile and repository
+// overrides selected by opts, and environment variables. A missing config
+// file is not an error.
+func Load(path string, opts LoadOptions) (*Config, error) {
 	config := DefaultConfig()
 
 	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 - path is validated by caller
-	if err != nil {
-		if os.IsNotExist(err) {
-			return config, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("reading config file: %w", err)
 	}
 
@@ -74,9 +161,36 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("parsing config file: %w", err)
 	}
 
-	// Override with environment variables if set
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		config.GitHub.Token = token
+	// Overrides for the repository in a profile take precedence over the
+	// top-level ones, so keep them apart while the profile is applied
+	repoOverrides := []map[string]yaml.Node{config.Repos}
+	if opts.Profile != "" {
+		profile, ok := config.Profiles[opts.Profile]
+		if !ok {
+			return nil, fmt.Errorf("profile %q is not defined in %s", opts.Profile, path)
+		}
+		config.Repos = nil
+		if err := profile.Decode(config); err != nil {
+			return nil, fmt.Errorf("parsing profile %q: %w", opts.Profile, err)
+		}
+		repoOverrides = append(repoOverrides, config.Repos)
+	}
+
+	if opts.Repo != "" {
+		for _, overrides := range repoOverrides {
+			for name, node := range overrides {
+				if !strings.EqualFold(name, opts.Repo) {
+					continue
+				}
+				if err := node.Decode(config); err != nil {
+					return nil, fmt.Errorf("parsing overrides for %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	if err := config.applyEnv(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `# team settings
+github:
+  api_url: https://api.github.com
+fetch:
+  concurrency: 6
+  api: graphql
+repos:
+  acme/big:
+    fetch:
+      concurrency: 2
+      max_retries: 9
+profiles:
+  work:
+    github:
+      api_url: https://ghe.example.com/api/v3
+    fetch:
+      concurrency: 8
+    repos:
+      acme/big:
+        fetch:
+          concurrency: 3
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	return path
+}
+
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, e := range githubEnv {
+		t.Setenv(e.env, "")
+	}
+	for _, key := range Keys() {
+		t.Setenv(EnvName(key), "")
+	}
+}
+
+func TestLoadAppliesLayersInOrder(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, testConfig)
+
+	tests := []struct {
+		name        string
+		opts        LoadOptions
+		env         map[string]string
+		apiURL      string
+		concurrency int
+		maxRetries  int
+		api         string
+	}{
+		{
+			name:        "file",
+			apiURL:      "https://api.github.com",
+			concurrency: 6,
+			maxRetries:  5,
+			api:         "graphql",
+		},
+		{
+			name:        "repository overrides",
+			opts:        LoadOptions{Repo: "Acme/Big"},
+			apiURL:      "https://api.github.com",
+			concurrency: 2,
+			maxRetries:  9,
+			api:         "graphql",
+		},
+		{
+			name:        "profile",
+			opts:        LoadOptions{Profile: "work"},
+			apiURL:      "https://ghe.example.com/api/v3",
+			concurrency: 8,
+			maxRetries:  5,
+			api:         "graphql",
+		},
+		{
+			name:        "profile repository overrides win over top-level ones",
+			opts:        LoadOptions{Profile: "work", Repo: "acme/big"},
+			apiURL:      "https://ghe.example.com/api/v3",
+			concurrency: 3,
+			maxRetries:  9,
+			api:         "graphql",
+		},
+		{
+			name: "environment",
+			opts: LoadOptions{Profile: "work", Repo: "acme/big"},
+			env: map[string]string{
+				"GITHUB_API_URL":                "https://ghe.internal/api/v3",
+				"PR_ANALYZER_FETCH_CONCURRENCY": "12",
+				"PR_ANALYZER_FETCH_API":         "rest",
+			},
+			apiURL:      "https://ghe.internal/api/v3",
+			concurrency: 12,
+			maxRetries:  9,
+			api:         "rest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load(path, tt.opts)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.GitHub.APIURL != tt.apiURL {
+				t.Errorf("api_url = %q, want %q", cfg.GitHub.APIURL, tt.apiURL)
+			}
+			if cfg.Fetch.Concurrency != tt.concurrency {
+				t.Errorf("concurrency = %d, want %d", cfg.Fetch.Concurrency, tt.concurrency)
+			}
+			if cfg.Fetch.MaxRetries != tt.maxRetries {
+				t.Errorf("max_retries = %d, want %d", cfg.Fetch.MaxRetries, tt.maxRetries)
+			}
+			if cfg.Fetch.API != tt.api {
+				t.Errorf("api = %q, want %q", cfg.Fetch.API, tt.api)
+			}
+			// Settings absent from every layer keep their defaults
+			if cfg.Fetch.RateLimitBuffer != 100 {
+				t.Errorf("rate_limit_buffer = %d, want the default 100", cfg.Fetch.RateLimitBuffer)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownProfile(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, testConfig)
+
+	if _, err := Load(path, LoadOptions{Profile: "home"}); err == nil {
+		t.Fatal("Load() with an undefined profile succeeded")
+	}
+}
+
+func TestLoadWithoutFileUsesEnvironment(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("GITHUB_TOKENS", "one, two")
+	t.Setenv("GITHUB_APP_ID", "42")
+	t.Setenv("PR_ANALYZER_FETCH_RETRY_MAX_ELAPSED", "45s")
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := strings.Join(cfg.GitHub.Tokens, ","); got != "one,two" {
+		t.Errorf("tokens = %q, want one,two", got)
+	}
+	if cfg.GitHub.App.AppID != 42 {
+		t.Errorf("app_id = %d, want 42", cfg.GitHub.App.AppID)
+	}
+	if cfg.Fetch.RetryMaxElapsed != 45*time.Second {
+		t.Errorf("retry_max_elapsed = %v, want 45s", cfg.Fetch.RetryMaxElapsed)
+	}
+
+	t.Setenv("PR_ANALYZER_FETCH_CONCURRENCY", "many")
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), LoadOptions{}); err == nil {
+		t.Error("Load() accepted a malformed environment override")
+	}
+}
+
+func TestSetInFileKeepsComments(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, testConfig)
+
+	if err := SetInFile(path, LoadOptions{Profile: "work", Repo: "acme/web"}, "fetch.api", "rest"); err != nil {
+		t.Fatalf("SetInFile() error = %v", err)
+	}
+	if err := SetInFile(path, LoadOptions{}, "fetch.concurrency", "10"); err != nil {
+		t.Fatalf("SetInFile() error = %v", err)
+	}
+	if err := SetInFile(path, LoadOptions{}, "fetch.concurrency", "ten"); err == nil {
+		t.Error("SetInFile() accepted a malformed value")
+	}
+	if err := SetInFile(path, LoadOptions{}, "fetch.unknown", "1"); err == nil {
+		t.Error("SetInFile() accepted an unknown key")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading config: %v", err)
+	}
+	if !strings.Contains(string(data), "# team settings") {
+		t.Errorf("comment was dropped:\n%s", data)
+	}
+
+	cfg, err := Load(path, LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Fetch.Concurrency != 10 {
+		t.Errorf("concurrency = %d, want 10", cfg.Fetch.Concurrency)
+	}
+
+	cfg, err = Load(path, LoadOptions{Profile: "work", Repo: "acme/web"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Fetch.API != "rest" {
+		t.Errorf("api = %q, want rest", cfg.Fetch.API)
+	}
+}
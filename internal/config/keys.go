@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix prefixes the environment variable of every setting, e.g.
+// PR_ANALYZER_FETCH_CONCURRENCY for fetch.concurrency.
+const envPrefix = "PR_ANALYZER_"
+
+// githubEnv maps the conventional GitHub environment variables to the
+// settings they override. PR_ANALYZER_* variables take precedence.
+var githubEnv = []struct{ env, key string }{
+	{"GITHUB_TOKEN", "github.token"},
+	{"GITHUB_TOKENS", "github.tokens"},
+	{"GITHUB_API_URL", "github.api_url"},
+	{"GITHUB_APP_ID", "github.app.app_id"},
+	{"GITHUB_APP_INSTALLATION_ID", "github.app.installation_id"},
+	{"GITHUB_APP_PRIVATE_KEY_PATH", "github.app.private_key_path"},
+}
+
+type setting struct {
+	key string
+	typ reflect.Type
+}
+
+// settings lists the leaf fields of Config by their dotted YAML path.
+// Profiles and repository overrides are layers, not settings.
+func settings() []setting {
+	var list []setting
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" || field.Type.Kind() == reflect.Map {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				walk(field.Type, prefix+name+".")
+				continue
+			}
+			list = append(list, setting{key: prefix + name, typ: field.Type})
+		}
+	}
+	walk(reflect.TypeFor[Config](), "")
+	return list
+}
+
+// Keys returns the names accepted by Set, such as fetch.concurrency.
+func Keys() []string {
+	var keys []string
+	for _, s := range settings() {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Set parses value as the setting key. Lists are comma separated and
+// durations use Go syntax, e.g. 90s.
+func (c *Config) Set(key, value string) error {
+	node, err := valueNode(key, value)
+	if err != nil {
+		return err
+	}
+	if err := nest(key, node).Decode(c); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+	return nil
+}
+
+func (c *Config) applyEnv() error {
+	for _, e := range githubEnv {
+		if value := os.Getenv(e.env); value != "" {
+			if err := c.Set(e.key, value); err != nil {
+				return fmt.Errorf("%s: %w", e.env, err)
+			}
+		}
+	}
+	for _, key := range Keys() {
+		if value := os.Getenv(EnvName(key)); value != "" {
+			if err := c.Set(key, value); err != nil {
+				return fmt.Errorf("%s: %w", EnvName(key), err)
+			}
+		}
+	}
+	return nil
+}
+
+// valueNode returns the YAML node for value as the setting key.
+func valueNode(key, value string) (*yaml.Node, error) {
+	i := slices.IndexFunc(settings(), func(s setting) bool { return s.key == key })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+
+	switch typ := settings()[i].typ; typ.Kind() {
+	case reflect.Slice:
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		return list, nil
+	case reflect.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value}, nil
+	}
+}
+
+// nest wraps value in the mappings named by the dotted key.
+func nest(key string, value *yaml.Node) *yaml.Node {
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		value = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: parts[i]},
+			value,
+		}}
+	}
+	return value
+}
+
+// SetInFile sets key to value in the config file at path, in the profile
+// and repository overrides selected by opts. The rest of the file,
+// including comments, is kept as is.
+func SetInFile(path string, opts LoadOptions, key, value string) error {
+	// Reject unknown keys and malformed values before touching the file
+	if err := DefaultConfig().Set(key, value); err != nil {
+		return err
+	}
+	node, err := valueNode(key, value)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 - path is validated by caller
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+
+	var parts []string
+	if opts.Profile != "" {
+		parts = append(parts, "profiles", opts.Profile)
+	}
+	if opts.Repo != "" {
+		parts = append(parts, "repos", opts.Repo)
+	}
+	parts = append(parts, strings.Split(key, ".")...)
+
+	mapping := doc.Content[0]
+	for _, part := range parts[:len(parts)-1] {
+		mapping, err = child(mapping, part)
+		if err != nil {
+			return fmt.Errorf("updating %s: %w", path, err)
+		}
+	}
+	setChild(mapping, parts[len(parts)-1], node)
+
+	data, err = yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("marshaling config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return nil
+}
+
+// child returns the mapping stored under name in mapping, adding an empty
+// one if there is none.
+func child(mapping *yaml.Node, name string) (*yaml.Node, error) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != name {
+			continue
+		}
+		value := mapping.Content[i+1]
+		// An empty key such as "fetch:" parses as null
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			*value = yaml.Node{Kind: yaml.MappingNode}
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", name)
+		}
+		return value, nil
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	setChild(mapping, name, value)
+	return value, nil
+}
+
+func setChild(mapping *yaml.Node, name string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+}
+
+// Redacted returns a copy of the resolved settings that is safe to print:
+// tokens are masked and the profile and repository layers are left out.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redacted.Profiles = nil
+	redacted.Repos = nil
+	redacted.GitHub.Token = mask(c.GitHub.Token)
+	redacted.GitHub.Tokens = nil
+	for _, token := range c.GitHub.Tokens {
+		redacted.GitHub.Tokens = append(redacted.GitHub.Tokens, mask(token))
+	}
+	return &redacted
+}
+
+func mask(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 8 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+// CISummary condenses the cached CI results of a PR.
+type CISummary struct {
+	// Checks counts check runs and commit statuses across all head commits
+	Checks int `json:"checks"`
+	// Reruns counts check runs repeated for the same commit
+	Reruns int `json:"reruns"`
+	// WallTimeSeconds adds up, for each head commit, the time from the
+	// first check starting to the last one completing
+	WallTimeSeconds int64 `json:"wall_time_seconds"`
+	// FailingChecks names the checks whose latest run on the current head
+	// commit failed
+	FailingChecks []string `json:"failing_checks,omitempty"`
+}
+
+// failedConclusions are the conclusions that count as a failing check.
+var failedConclusions = []string{"failure", "timed_out", "startup_failure", "error"}
+
+// summarizeCI returns the CI summary of pr, or nil if no checks are cached.
+func summarizeCI(pr *models.PullRequest) *CISummary {
+	if len(pr.Checks) == 0 {
+		return nil
+	}
+
+	type checkKey struct{ sha, kind, name string }
+	type span struct{ start, end time.Time }
+
+	summary := &CISummary{Checks: len(pr.Checks)}
+	runs := make(map[checkKey]int)
+	spans := make(map[string]*span)
+	// Checks come ordered by start time, so later runs replace earlier ones
+	latest := make(map[string]string)
+
+	for _, check := range pr.Checks {
+		runs[checkKey{check.HeadSHA, check.Kind, check.Name}]++
+		if check.HeadSHA == pr.HeadSHA {
+			latest[check.Name] = check.Conclusion
+		}
+
+		if check.StartedAt == nil || check.CompletedAt == nil {
+			continue
+		}
+		s, ok := spans[check.HeadSHA]
+		if !ok {
+			spans[check.HeadSHA] = &span{start: *check.StartedAt, end: *check.CompletedAt}
+			continue
+		}
+		if check.StartedAt.Before(s.start) {
+			s.start = *check.StartedAt
+		}
+		if check.CompletedAt.After(s.end) {
+			s.end = *check.CompletedAt
+		}
+	}
+
+	for _, count := range runs {
+		summary.Reruns += count - 1
+	}
+	for _, s := range spans {
+		summary.WallTimeSeconds += int64(s.end.Sub(s.start).Seconds())
+	}
+	for name, conclusion := range latest {
+		if slices.Contains(failedConclusions, conclusion) {
+			summary.FailingChecks = append(summary.FailingChecks, name)
+		}
+	}
+	slices.Sort(summary.FailingChecks)
+
+	return summary
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+func TestSummarizeCI(t *testing.T) {
+	at := func(minute int) *time.Time {
+		ts := time.Date(2025, 1, 1, 12, minute, 0, 0, time.UTC)
+		return &ts
+	}
+	pr := &models.PullRequest{
+		HeadSHA: "new",
+		Checks: []models.CheckRun{
+			// An earlier head commit: 10 minutes, failures no longer count
+			{Kind: "check_run", HeadSHA: "old", Name: "test", Conclusion: "failure", StartedAt: at(0), CompletedAt: at(10)},
+			// The head commit: 20 minutes with test re-run until it passed
+			{Kind: "check_run", HeadSHA: "new", Name: "test", Conclusion: "failure", StartedAt: at(20), CompletedAt: at(25)},
+			{Kind: "check_run", HeadSHA: "new", Name: "lint", Conclusion: "timed_out", StartedAt: at(21), CompletedAt: at(40)},
+			{Kind: "check_run", HeadSHA: "new", Name: "test", Conclusion: "success", StartedAt: at(30), CompletedAt: at(35)},
+			{Kind: "status", HeadSHA: "new", Name: "ci/jenkins", Conclusion: "error", StartedAt: at(22), CompletedAt: at(23)},
+			{Kind: "check_run", HeadSHA: "new", Name: "deploy", Status: "in_progress", StartedAt: at(41)},
+		},
+	}
+
+	got := summarizeCI(pr)
+	if got == nil {
+		t.Fatal("summarizeCI() = nil")
+	}
+	if got.Checks != 6 {
+		t.Errorf("Checks = %d, want 6", got.Checks)
+	}
+	if got.Reruns != 1 {
+		t.Errorf("Reruns = %d, want 1", got.Reruns)
+	}
+	if got.WallTimeSeconds != 30*60 {
+		t.Errorf("WallTimeSeconds = %d, want %d", got.WallTimeSeconds, 30*60)
+	}
+	if want := []string{"ci/jenkins", "lint"}; !slices.Equal(got.FailingChecks, want) {
+		t.Errorf("FailingChecks = %v, want %v", got.FailingChecks, want)
+	}
+
+	if got := summarizeCI(&models.PullRequest{}); got != nil {
+		t.Errorf("summarizeCI() without checks = %+v, want nil", got)
+	}
+}
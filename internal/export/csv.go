@@ -3,6 +3,7 @@ package export
 import (
 	"encoding/csv"
 	"fmt"
+	"iter"
 	"os"
 	"strconv"
 	"strings"
@@ -23,23 +24,27 @@ func NewCSVExporter(filename string, includeDiffs bool) *CSVExporter {
 	}
 }
 
-func (e *CSVExporter) Export(prs []*models.PullRequest) error {
+func (e *CSVExporter) Export(prs iter.Seq2[*models.PullRequest, error]) (int, error) {
 	file, err := os.Create(e.filename)
 	if err != nil {
-		return fmt.Errorf("creating output file: %w", err)
+		return 0, fmt.Errorf("creating output file: %w", err)
 	}
 	defer file.Close()
 
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
-	// Write header
+	// Write header. New columns go after the existing ones, so that readers
+	// relying on column positions keep working.
 	header := []string{
 		"number", "title", "state", "author", "author_type", "author_is_bot",
 		"assignees", "requested_reviewers", "labels",
 		"created_at", "updated_at", "merged_at",
 		"additions", "deletions", "changed_files", "comments", "review_comments", "reviews",
 		"review_states", "comment_authors", "file_paths",
+		"body", "draft", "author_association", "requested_teams", "milestone",
+		"base_ref", "base_sha", "head_ref", "head_sha", "closed_at", "merged_by", "merge_commit_sha",
+		"commits", "commit_authors",
+		"ci_checks", "ci_reruns", "ci_wall_time_seconds", "ci_failing_checks",
 	}
 
 	if e.includeDiffs {
@@ -47,18 +52,27 @@ func (e *CSVExporter) Export(prs []*models.PullRequest) error {
 	}
 
 	if err := writer.Write(header); err != nil {
-		return fmt.Errorf("writing CSV header: %w", err)
+		return 0, fmt.Errorf("writing CSV header: %w", err)
 	}
 
 	// Write data rows
-	for _, pr := range prs {
+	count := 0
+	for pr, err := range prs {
+		if err != nil {
+			return count, fmt.Errorf("reading PRs: %w", err)
+		}
 		row := e.transformPRToRow(pr)
 		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("writing PR %d: %w", pr.Number, err)
+			return count, fmt.Errorf("writing PR %d: %w", pr.Number, err)
 		}
+		count++
 	}
 
-	return nil
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, fmt.Errorf("writing output file: %w", err)
+	}
+	return count, file.Close()
 }
 
 func (e *CSVExporter) transformPRToRow(pr *models.PullRequest) []string {
@@ -85,7 +99,23 @@ func (e *CSVExporter) transformPRToRow(pr *models.PullRequest) []string {
 		e.getReviewStates(pr.Reviews),
 		e.getCommentAuthors(pr.Comments),
 		e.getFilePaths(pr.Files),
+		// The body is written in full; the CSV writer quotes its newlines
+		pr.Body,
+		strconv.FormatBool(pr.Draft),
+		pr.AuthorAssociation,
+		strings.Join(pr.RequestedTeams, ";"),
+		pr.Milestone,
+		pr.BaseRef,
+		pr.BaseSHA,
+		pr.HeadRef,
+		pr.HeadSHA,
+		e.formatOptionalTime(pr.ClosedAt),
+		e.mergedBy(pr.MergedBy),
+		pr.MergeCommitSHA,
+		strconv.Itoa(pr.Stats.Commits),
+		e.getCommitAuthors(pr.Commits),
 	}
+	row = append(row, e.getCISummary(pr)...)
 
 	// Add diff summary if requested
 	if e.includeDiffs {
@@ -95,6 +125,20 @@ func (e *CSVExporter) transformPRToRow(pr *models.PullRequest) []string {
 	return row
 }
 
+// getCISummary returns the CI columns, left empty when no checks are cached.
+func (e *CSVExporter) getCISummary(pr *models.PullRequest) []string {
+	summary := summarizeCI(pr)
+	if summary == nil {
+		return []string{"", "", "", ""}
+	}
+	return []string{
+		strconv.Itoa(summary.Checks),
+		strconv.Itoa(summary.Reruns),
+		strconv.FormatInt(summary.WallTimeSeconds, 10),
+		strings.Join(summary.FailingChecks, ";"),
+	}
+}
+
 func (e *CSVExporter) escapeCsvValue(value string) string {
 	// Remove newlines and tabs, truncate if too long
 	cleaned := strings.ReplaceAll(value, "\n", " ")
@@ -132,6 +176,13 @@ func (e *CSVExporter) serializeLabels(labels []models.Label) string {
 	return strings.Join(labelNames, ";")
 }
 
+func (e *CSVExporter) mergedBy(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.Login
+}
+
 func (e *CSVExporter) formatOptionalTime(t *time.Time) string {
 	if t == nil {
 		return ""
@@ -173,6 +224,34 @@ func (e *CSVExporter) getCommentAuthors(comments []models.Comment) string {
 	return strings.Join(authors, ";")
 }
 
+// getCommitAuthors lists everyone credited with a commit, including
+// co-authors, in order of their first commit.
+func (e *CSVExporter) getCommitAuthors(commits []models.Commit) string {
+	if len(commits) == 0 {
+		return ""
+	}
+
+	seen := make(map[string]bool)
+	var authors []string
+	add := func(identity models.CommitIdentity) {
+		name := identity.Login
+		if name == "" {
+			name = identity.Email
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			authors = append(authors, name)
+		}
+	}
+	for _, commit := range commits {
+		add(commit.Author)
+		for _, coAuthor := range commit.CoAuthors {
+			add(coAuthor)
+		}
+	}
+	return strings.Join(authors, ";")
+}
+
 func (e *CSVExporter) getFilePaths(files []models.File) string {
 	if len(files) == 0 {
 		return ""
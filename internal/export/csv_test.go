@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+func TestCSVExportKeepsColumnPositions(t *testing.T) {
+	// The columns of the first CSV export, which readers may address by position
+	original := []string{
+		"number", "title", "state", "author", "author_type", "author_is_bot",
+		"assignees", "requested_reviewers", "labels",
+		"created_at", "updated_at", "merged_at",
+		"additions", "deletions", "changed_files", "comments", "review_comments", "reviews",
+		"review_states", "comment_authors", "file_paths",
+	}
+	body := "Fixes the parser.\n\n" + strings.Repeat("Details. ", 50)
+
+	filename := filepath.Join(t.TempDir(), "pulls.csv")
+	prs := func(yield func(*models.PullRequest, error) bool) {
+		yield(&models.PullRequest{Number: 7, Title: "Fix parser", Body: body, State: "open"}, nil)
+	}
+	if _, err := NewCSVExporter(filename, true).Export(prs); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+
+	header, row := records[0], records[1]
+	if !slices.Equal(header[:len(original)], original) {
+		t.Errorf("header starts with %v, want %v", header[:len(original)], original)
+	}
+	if header[len(header)-1] != "diff_summary" {
+		t.Errorf("last column = %q, want diff_summary", header[len(header)-1])
+	}
+	if row[0] != "7" || row[2] != "open" {
+		t.Errorf("row = %v, want PR 7 with its state in column 3", row)
+	}
+	if i := slices.Index(header, "body"); i < 0 {
+		t.Errorf("header %v has no body column", header)
+	} else if row[i] != body {
+		t.Errorf("body = %q, want the full body", row[i])
+	}
+}
@@ -1,8 +1,10 @@
 package export
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"os"
 
 	"github.com/bonyuta0204/pr-analyzer/pkg/models"
@@ -20,33 +22,43 @@ func NewJSONLExporter(filename string, includeDiffs bool) *JSONLExporter {
 	}
 }
 
-func (e *JSONLExporter) Export(prs []*models.PullRequest) error {
+func (e *JSONLExporter) Export(prs iter.Seq2[*models.PullRequest, error]) (int, error) {
 	file, err := os.Create(e.filename)
 	if err != nil {
-		return fmt.Errorf("creating output file: %w", err)
+		return 0, fmt.Errorf("creating output file: %w", err)
 	}
 	defer file.Close()
 
-	for _, pr := range prs {
+	writer := bufio.NewWriter(file)
+	count := 0
+	for pr, err := range prs {
+		if err != nil {
+			return count, fmt.Errorf("reading PRs: %w", err)
+		}
+
 		// Create export record
 		exportPR := e.transformPR(pr)
 
 		// Marshal to JSON
 		jsonData, err := json.Marshal(exportPR)
 		if err != nil {
-			return fmt.Errorf("marshaling PR %d: %w", pr.Number, err)
+			return count, fmt.Errorf("marshaling PR %d: %w", pr.Number, err)
 		}
 
 		// Write JSONL line
-		if _, err := file.Write(jsonData); err != nil {
-			return fmt.Errorf("writing PR %d: %w", pr.Number, err)
+		if _, err := writer.Write(jsonData); err != nil {
+			return count, fmt.Errorf("writing PR %d: %w", pr.Number, err)
 		}
-		if _, err := file.WriteString("\n"); err != nil {
-			return fmt.Errorf("writing newline for PR %d: %w", pr.Number, err)
+		if err := writer.WriteByte('\n'); err != nil {
+			return count, fmt.Errorf("writing newline for PR %d: %w", pr.Number, err)
 		}
+		count++
 	}
 
-	return nil
+	if err := writer.Flush(); err != nil {
+		return count, fmt.Errorf("writing output file: %w", err)
+	}
+	return count, file.Close()
 }
 
 func (e *JSONLExporter) transformPR(pr *models.PullRequest) ExportPullRequest {
@@ -54,17 +66,33 @@ func (e *JSONLExporter) transformPR(pr *models.PullRequest) ExportPullRequest {
 		Type:               "pull",
 		Number:             pr.Number,
 		Title:              pr.Title,
+		Body:               pr.Body,
 		State:              pr.State,
+		Draft:              pr.Draft,
 		Author:             pr.Author,
+		AuthorAssociation:  pr.AuthorAssociation,
 		Assignees:          pr.Assignees,
 		RequestedReviewers: pr.RequestedReviewers,
+		RequestedTeams:     pr.RequestedTeams,
 		Labels:             pr.Labels,
+		Milestone:          pr.Milestone,
 		CreatedAt:          pr.CreatedAt,
 		UpdatedAt:          pr.UpdatedAt,
+		ClosedAt:           pr.ClosedAt,
 		MergedAt:           pr.MergedAt,
+		MergedBy:           pr.MergedBy,
+		MergeCommitSHA:     pr.MergeCommitSHA,
+		BaseRef:            pr.BaseRef,
+		BaseSHA:            pr.BaseSHA,
+		HeadRef:            pr.HeadRef,
+		HeadSHA:            pr.HeadSHA,
 		Stats:              pr.Stats,
 		Reviews:            pr.Reviews,
-		Comments:           e.transformComments(pr.Comments),
+		Comments:           e.transformComments(pr.Comments, pr.Threads),
+		Commits:            pr.Commits,
+		Timeline:           pr.Timeline,
+		Checks:             pr.Checks,
+		CI:                 summarizeCI(pr),
 	}
 
 	// Include files based on includeDiffs setting
@@ -87,44 +115,127 @@ func (e *JSONLExporter) transformPR(pr *models.PullRequest) ExportPullRequest {
 	return exportPR
 }
 
-func (e *JSONLExporter) transformComments(comments []models.Comment) []ExportComment {
+// transformComments lists issue comments and the first comment of each
+// review thread, with the replies nested in the thread.
+func (e *JSONLExporter) transformComments(comments []models.Comment, threads []models.CommentThread) []ExportComment {
+	states := make(map[int64]*models.CommentThread, len(threads))
+	for i := range threads {
+		states[threads[i].RootID] = &threads[i]
+	}
+	roots := threadRoots(comments)
+
 	var exportComments []ExportComment
+	// Position of each thread's first comment in exportComments
+	threadIndex := make(map[int64]int)
+	var replies []ExportComment
+	var replyRoots []int64
 
 	for _, comment := range comments {
-		exportComment := ExportComment{
-			Type:      e.getCommentType(comment),
-			PRNumber:  comment.PullNumber,
-			CommentID: comment.ID,
-			Author:    comment.Author,
-			Body:      comment.Body,
-			CreatedAt: comment.CreatedAt,
-			Reactions: comment.Reactions,
+		exportComment := e.transformComment(comment)
+		if comment.Path == "" {
+			exportComments = append(exportComments, exportComment)
+			continue
 		}
 
-		// Add code-specific fields if it's a review comment
-		if comment.Path != "" {
-			exportComment.FilePath = comment.Path
-			exportComment.Line = comment.Line
-			exportComment.Side = comment.Side
-
-			if e.includeDiffs && comment.DiffHunk != "" {
-				exportComment.CodeContext = &models.CodeContext{
-					DiffHunk: comment.DiffHunk,
-				}
+		root := roots[comment.ID]
+		state := states[root]
+		if state != nil {
+			if reason, ok := state.Minimized[comment.ID]; ok {
+				exportComment.IsMinimized = true
+				exportComment.MinimizedReason = reason
 			}
 		}
 
-		// Add updated time if different from created
-		if !comment.UpdatedAt.Equal(comment.CreatedAt) {
-			exportComment.UpdatedAt = &comment.UpdatedAt
+		if root != comment.ID {
+			replies = append(replies, exportComment)
+			replyRoots = append(replyRoots, root)
+			continue
 		}
 
+		exportComment.Thread = &ExportThread{}
+		if state != nil {
+			exportComment.Thread.IsResolved = state.IsResolved
+			exportComment.Thread.ResolvedBy = state.ResolvedBy
+			exportComment.Thread.IsOutdated = state.IsOutdated
+		}
+		threadIndex[root] = len(exportComments)
 		exportComments = append(exportComments, exportComment)
 	}
 
+	for i, reply := range replies {
+		index, ok := threadIndex[replyRoots[i]]
+		if !ok {
+			exportComments = append(exportComments, reply)
+			continue
+		}
+		thread := exportComments[index].Thread
+		thread.Replies = append(thread.Replies, reply)
+	}
+
 	return exportComments
 }
 
+// threadRoots maps each code comment to the first comment of its thread,
+// following InReplyToID. A reply whose parent is gone starts its own thread.
+func threadRoots(comments []models.Comment) map[int64]int64 {
+	parents := make(map[int64]*int64)
+	for _, comment := range comments {
+		if comment.Path != "" {
+			parents[comment.ID] = comment.InReplyToID
+		}
+	}
+
+	roots := make(map[int64]int64, len(parents))
+	for id := range parents {
+		root := id
+		// The step limit guards against reply cycles in corrupt data
+		for range len(parents) {
+			parent := parents[root]
+			if parent == nil {
+				break
+			}
+			if _, ok := parents[*parent]; !ok {
+				break
+			}
+			root = *parent
+		}
+		roots[id] = root
+	}
+	return roots
+}
+
+func (e *JSONLExporter) transformComment(comment models.Comment) ExportComment {
+	exportComment := ExportComment{
+		Type:      e.getCommentType(comment),
+		PRNumber:  comment.PullNumber,
+		CommentID: comment.ID,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		Reactions: comment.Reactions,
+	}
+
+	// Add code-specific fields if it's a review comment
+	if comment.Path != "" {
+		exportComment.FilePath = comment.Path
+		exportComment.Line = comment.Line
+		exportComment.Side = comment.Side
+
+		if e.includeDiffs && comment.DiffHunk != "" {
+			exportComment.CodeContext = &models.CodeContext{
+				DiffHunk: comment.DiffHunk,
+			}
+		}
+	}
+
+	// Add updated time if different from created
+	if !comment.UpdatedAt.Equal(comment.CreatedAt) {
+		exportComment.UpdatedAt = &comment.UpdatedAt
+	}
+
+	return exportComment
+}
+
 func (e *JSONLExporter) getCommentType(comment models.Comment) string {
 	if comment.Path != "" {
 		return "code_comment"
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+func TestTransformCommentsNestsReviewThreads(t *testing.T) {
+	id := func(n int64) *int64 { return &n }
+	comments := []models.Comment{
+		{ID: 1, Body: "Thanks!"},
+		{ID: 2, Path: "main.go", Body: "Nit"},
+		{ID: 3, Path: "go.mod", Body: "Why this version?"},
+		{ID: 4, Path: "main.go", Body: "Fixed", InReplyToID: id(2)},
+		{ID: 5, Path: "main.go", Body: "Thanks", InReplyToID: id(4)},
+		// The comment replied to was deleted
+		{ID: 6, Path: "util.go", Body: "Done", InReplyToID: id(99)},
+	}
+	threads := []models.CommentThread{{
+		RootID:     2,
+		IsResolved: true,
+		ResolvedBy: &models.User{Login: "carol"},
+		Minimized:  map[int64]string{4: "outdated"},
+	}}
+
+	got := NewJSONLExporter("", false).transformComments(comments, threads)
+
+	var ids []int64
+	for _, comment := range got {
+		ids = append(ids, comment.CommentID)
+	}
+	if len(got) != 4 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 || ids[3] != 6 {
+		t.Fatalf("top-level comments = %v, want 1, 2, 3 and 6", ids)
+	}
+	if got[0].Thread != nil {
+		t.Errorf("issue comment has thread %+v", got[0].Thread)
+	}
+
+	thread := got[1].Thread
+	if thread == nil || !thread.IsResolved || thread.ResolvedBy == nil || thread.ResolvedBy.Login != "carol" {
+		t.Fatalf("thread = %+v, want it resolved by carol", thread)
+	}
+	if len(thread.Replies) != 2 || thread.Replies[0].CommentID != 4 || thread.Replies[1].CommentID != 5 {
+		t.Errorf("replies = %+v, want 4 and 5", thread.Replies)
+	}
+	if reply := thread.Replies[0]; !reply.IsMinimized || reply.MinimizedReason != "outdated" {
+		t.Errorf("reply 4 minimized = %v %q, want outdated", reply.IsMinimized, reply.MinimizedReason)
+	}
+
+	if thread := got[2].Thread; thread == nil || thread.IsResolved || len(thread.Replies) != 0 {
+		t.Errorf("thread of comment 3 = %+v, want an unresolved thread without replies", thread)
+	}
+}
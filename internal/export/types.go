@@ -1,6 +1,7 @@
 package export
 
 import (
+	"iter"
 	"time"
 
 	"github.com/bonyuta0204/pr-analyzer/pkg/models"
@@ -11,18 +12,34 @@ type ExportPullRequest struct {
 	Type               string                  `json:"type"`
 	Number             int                     `json:"number"`
 	Title              string                  `json:"title"`
+	Body               string                  `json:"body,omitempty"`
 	State              string                  `json:"state"`
+	Draft              bool                    `json:"draft"`
 	Author             models.User             `json:"author"`
+	AuthorAssociation  string                  `json:"author_association,omitempty"`
 	Assignees          []models.User           `json:"assignees,omitempty"`
 	RequestedReviewers []models.User           `json:"requested_reviewers,omitempty"`
+	RequestedTeams     []string                `json:"requested_teams,omitempty"`
 	Labels             []models.Label          `json:"labels,omitempty"`
+	Milestone          string                  `json:"milestone,omitempty"`
 	CreatedAt          time.Time               `json:"created_at"`
 	UpdatedAt          time.Time               `json:"updated_at"`
+	ClosedAt           *time.Time              `json:"closed_at,omitempty"`
 	MergedAt           *time.Time              `json:"merged_at,omitempty"`
+	MergedBy           *models.User            `json:"merged_by,omitempty"`
+	MergeCommitSHA     string                  `json:"merge_commit_sha,omitempty"`
+	BaseRef            string                  `json:"base_ref,omitempty"`
+	BaseSHA            string                  `json:"base_sha,omitempty"`
+	HeadRef            string                  `json:"head_ref,omitempty"`
+	HeadSHA            string                  `json:"head_sha,omitempty"`
 	Stats              models.PullRequestStats `json:"stats"`
 	Files              []models.File           `json:"files,omitempty"`
 	Reviews            []models.Review         `json:"reviews,omitempty"`
 	Comments           []ExportComment         `json:"comments,omitempty"`
+	Commits            []models.Commit         `json:"commits,omitempty"`
+	Timeline           []models.TimelineEvent  `json:"timeline,omitempty"`
+	Checks             []models.CheckRun       `json:"checks,omitempty"`
+	CI                 *CISummary              `json:"ci,omitempty"`
 }
 
 // ExportComment represents a comment optimized for export with additional context
@@ -39,11 +56,26 @@ type ExportComment struct {
 	Reactions   map[string]int      `json:"reactions,omitempty"`
 	CreatedAt   time.Time           `json:"created_at"`
 	UpdatedAt   *time.Time          `json:"updated_at,omitempty"`
+	// MinimizedReason is set for comments hidden on GitHub, e.g. as outdated
+	IsMinimized     bool   `json:"is_minimized,omitempty"`
+	MinimizedReason string `json:"minimized_reason,omitempty"`
+	// Thread is set on the first code comment of a review thread
+	Thread *ExportThread `json:"thread,omitempty"`
 }
 
-// Exporter interface for different export formats
+// ExportThread holds the state and the replies of a review thread
+type ExportThread struct {
+	IsResolved bool            `json:"is_resolved"`
+	ResolvedBy *models.User    `json:"resolved_by,omitempty"`
+	IsOutdated bool            `json:"is_outdated"`
+	Replies    []ExportComment `json:"replies,omitempty"`
+}
+
+// Exporter interface for different export formats. Export writes each PR
+// as prs yields it and returns the number written, so output of any size
+// needs only one PR in memory.
 type Exporter interface {
-	Export(prs []*models.PullRequest) error
+	Export(prs iter.Seq2[*models.PullRequest, error]) (int, error)
 	GetFileSize() (int64, error)
 }
 
@@ -0,0 +1,160 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/config"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// GitHub rejects app JWTs valid for longer than ten minutes
+	appJWTLifetime = 9 * time.Minute
+	// Backdating absorbs clock skew between this host and GitHub
+	appJWTBackdate = time.Minute
+	// Installation tokens last an hour; renew them well before they expire
+	// so that a token never lapses in the middle of a page of requests
+	installationTokenRefresh = 5 * time.Minute
+)
+
+// appTokenSource exchanges a JWT signed with a GitHub App's private key for
+// an installation access token.
+type appTokenSource struct {
+	appID    int64
+	key      *rsa.PrivateKey
+	tokenURL string
+	client   *http.Client
+}
+
+// newAppTokenSource returns a token source for the installation configured
+// in cfg. Tokens are cached and renewed automatically before they expire.
+func newAppTokenSource(cfg config.GitHubConfig, client *http.Client) (oauth2.TokenSource, error) {
+	app := cfg.App
+	if app.InstallationID == 0 || app.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("GitHub App authentication needs an installation ID and a private key path")
+	}
+
+	key, err := loadAppPrivateKey(app.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &appTokenSource{
+		appID:    app.AppID,
+		key:      key,
+		tokenURL: fmt.Sprintf("%s/app/installations/%d/access_tokens", strings.TrimSuffix(cfg.APIURL, "/"), app.InstallationID),
+		client:   client,
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, src, installationTokenRefresh), nil
+}
+
+// loadAppPrivateKey reads a PEM encoded RSA key as downloaded from the
+// GitHub App settings (PKCS #1), or converted to PKCS #8.
+func loadAppPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 - path comes from the user's config
+	if err != nil {
+		return nil, fmt.Errorf("reading GitHub App private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// Token requests a new installation token.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating installation token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &APIError{Kind: ErrNetwork, Err: fmt.Errorf("requesting installation token: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		kind := ErrUnauthorized
+		if resp.StatusCode >= http.StatusInternalServerError {
+			kind = ErrServer
+		}
+		return nil, &APIError{
+			Kind:       kind,
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("requesting installation token for app %d: %s", s.appID, resp.Status),
+		}
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding installation token: %w", err)
+	}
+	if body.Token == "" {
+		return nil, errors.New("installation token response contains no token")
+	}
+
+	return &oauth2.Token{AccessToken: body.Token, TokenType: "Bearer", Expiry: body.ExpiresAt}, nil
+}
+
+// jwt signs the short-lived RS256 token that identifies the app.
+func (s *appTokenSource) jwt() (string, error) {
+	now := time.Now()
+	header := `{"alg":"RS256","typ":"JWT"}`
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTBackdate).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding JWT claims: %w", err)
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing JWT: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
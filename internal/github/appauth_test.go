@@ -0,0 +1,142 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/cache"
+	"github.com/bonyuta0204/pr-analyzer/internal/config"
+)
+
+// fakeTokenEndpoint issues installation tokens for app 7, installation 42,
+// checking the JWT signature. Each token expires after ttl.
+type fakeTokenEndpoint struct {
+	key    *rsa.PublicKey
+	ttl    time.Duration
+	issued atomic.Int32
+}
+
+func (f *fakeTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+		http.NotFound(w, r)
+		return
+	}
+	if err := f.verify(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	n := f.issued.Add(1)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"token":      fmt.Sprintf("ghs_%d", n),
+		"expires_at": time.Now().Add(f.ttl).Format(time.RFC3339),
+	})
+}
+
+func (f *fakeTokenEndpoint) verify(jwt string) error {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed JWT")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != "7" || time.Unix(claims.Exp, 0).Before(time.Now()) {
+		return fmt.Errorf("unexpected claims %+v", claims)
+	}
+	return nil
+}
+
+func TestNewClientAuthenticatesAsAppInstallation(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens expire within the refresh margin, so every request renews it
+	tokens := &fakeTokenEndpoint{key: &key.PublicKey, ttl: time.Minute}
+	var seen []string
+	mux := http.NewServeMux()
+	mux.Handle("/app/", tokens)
+	mux.HandleFunc("/repos/owner/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("[]"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store, err := cache.NewStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer store.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.GitHub.Token = ""
+	cfg.GitHub.APIURL = server.URL
+	cfg.GitHub.App = config.GitHubAppConfig{AppID: 7, InstallationID: 42, PrivateKeyPath: keyPath}
+	client, err := NewClient(cfg, store, "owner/repo")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.FetchPullRequests(t.Context(), FetchOptions{Refetch: true}); err != nil {
+			t.Fatalf("FetchPullRequests() error = %v", err)
+		}
+	}
+
+	want := []string{"Bearer ghs_1", "Bearer ghs_2"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("Authorization headers = %v, want %v", seen, want)
+	}
+
+	// Long-lived tokens are reused
+	tokens.ttl = time.Hour
+	for i := 0; i < 2; i++ {
+		if _, err := client.FetchPullRequests(t.Context(), FetchOptions{Refetch: true}); err != nil {
+			t.Fatalf("FetchPullRequests() error = %v", err)
+		}
+	}
+	if got := tokens.issued.Load(); got != 3 {
+		t.Errorf("issued tokens = %d, want 3", got)
+	}
+}
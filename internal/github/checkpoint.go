@@ -0,0 +1,190 @@
+package github
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+// ErrNoCheckpoint is returned when --resume finds no interrupted sync.
+var ErrNoCheckpoint = errors.New("no interrupted sync to resume")
+
+// syncRun holds the state of one listing pass over a repository's PRs. It
+// is checkpointed to the cache so that an interrupted sync can be resumed.
+type syncRun struct {
+	startedAt  time.Time
+	cutoff     time.Time
+	syncCutoff bool
+	unbounded  bool
+	limit      int
+	refetch    bool
+
+	mu            sync.Mutex
+	page          int
+	cursor        string
+	listed        int
+	pageListed    int
+	pending       map[int]struct{}
+	lastCompleted int
+}
+
+func newSyncRun(opts FetchOptions, meta *models.SyncMetadata) *syncRun {
+	run := &syncRun{
+		startedAt: time.Now(),
+		cutoff:    opts.Since,
+		unbounded: opts.Since.IsZero(),
+		limit:     opts.Limit,
+		refetch:   opts.Refetch,
+		pending:   make(map[int]struct{}),
+	}
+
+	// Without an explicit --since, stop listing at the previous sync. A
+	// full listing may only do so when the previous sync covered every PR.
+	if run.cutoff.IsZero() && !opts.Refetch && meta != nil && (meta.Complete || !opts.All) {
+		run.cutoff = meta.LastSyncAt
+		run.syncCutoff = true
+	}
+
+	if opts.All {
+		run.limit = 0
+	}
+
+	return run
+}
+
+func resumeSyncRun(cp *models.FetchCheckpoint) *syncRun {
+	run := &syncRun{
+		startedAt:     cp.StartedAt,
+		syncCutoff:    cp.SyncCutoff,
+		unbounded:     cp.Unbounded,
+		limit:         cp.Limit,
+		refetch:       cp.Refetch,
+		page:          cp.Page,
+		cursor:        cp.Cursor,
+		listed:        cp.Listed,
+		pageListed:    cp.Listed,
+		pending:       make(map[int]struct{}, len(cp.Pending)),
+		lastCompleted: cp.LastCompletedPR,
+	}
+	if cp.Cutoff != nil {
+		run.cutoff = *cp.Cutoff
+	}
+	for _, number := range cp.Pending {
+		run.pending[number] = struct{}{}
+	}
+	return run
+}
+
+// checkpoint captures the run so that a resumed sync restarts at the
+// beginning of the current listing page with the unfinished detail queue.
+func (r *syncRun) checkpoint(repo string) *models.FetchCheckpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cp := &models.FetchCheckpoint{
+		Repo:            repo,
+		Page:            r.page,
+		Cursor:          r.cursor,
+		Listed:          r.pageListed,
+		LastCompletedPR: r.lastCompleted,
+		Pending:         r.pendingLocked(),
+		Limit:           r.limit,
+		Refetch:         r.refetch,
+		SyncCutoff:      r.syncCutoff,
+		Unbounded:       r.unbounded,
+		StartedAt:       r.startedAt,
+		UpdatedAt:       time.Now(),
+	}
+	if !r.cutoff.IsZero() {
+		cutoff := r.cutoff
+		cp.Cutoff = &cutoff
+	}
+	return cp
+}
+
+func (r *syncRun) pendingNumbers() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pendingLocked()
+}
+
+func (r *syncRun) pendingLocked() []int {
+	numbers := make([]int, 0, len(r.pending))
+	for number := range r.pending {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// enqueue marks a PR as awaiting details. It is called before the PR row is
+// saved so that an interruption never leaves a PR without its details.
+func (r *syncRun) enqueue(number int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[number] = struct{}{}
+}
+
+func (r *syncRun) complete(number int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, number)
+	r.lastCompleted = number
+}
+
+// admit decides whether a PR updated at updatedAt is part of the run. When
+// it is not, listing stops for the returned reason.
+func (r *syncRun) admit(updatedAt time.Time) (listStop, bool) {
+	// Skip if PR hasn't been updated since the cutoff
+	if !r.cutoff.IsZero() && updatedAt.Before(r.cutoff) {
+		// Since results are sorted by updated desc, we can stop here
+		return listReachedCutoff, false
+	}
+	// Check if we've reached the limit
+	if r.countListed() {
+		return listReachedLimit, false
+	}
+	return listExhausted, true
+}
+
+// countListed records a listed PR and reports whether the limit was reached.
+func (r *syncRun) countListed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limit > 0 && r.listed >= r.limit {
+		return true
+	}
+	r.listed++
+	return false
+}
+
+// nextPage moves the run to the given listing page.
+func (r *syncRun) nextPage(page int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.page = page
+	r.pageListed = r.listed
+}
+
+// nextCursor moves the run to the GraphQL listing page after cursor.
+func (r *syncRun) nextCursor(cursor string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cursor = cursor
+	r.pageListed = r.listed
+}
+
+func (r *syncRun) currentCursor() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cursor
+}
+
+func (r *syncRun) currentPage() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.page
+}
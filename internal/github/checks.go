@@ -0,0 +1,177 @@
+package github
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+	"github.com/google/go-github/v50/github"
+)
+
+// Kinds of cached CI results
+const (
+	checkKindRun    = "check_run"
+	checkKindStatus = "status"
+)
+
+// FetchChecks caches the check runs, including re-runs, and the commit
+// statuses of a PR's head commit. Results for earlier head commits stay in
+// the cache.
+func (c *Client) FetchChecks(ctx context.Context, prNumber int) error {
+	if !c.config.Fetch.Checks {
+		return nil
+	}
+
+	cached, err := c.cache.GetPullRequest(c.GetRepository(), prNumber)
+	if err != nil {
+		return fmt.Errorf("reading cached PR %d: %w", prNumber, err)
+	}
+	if cached.HeadSHA == "" {
+		return nil
+	}
+
+	err = c.fetchCheckRuns(ctx, prNumber, cached.HeadSHA)
+	if err == nil {
+		err = c.fetchStatuses(ctx, prNumber, cached.HeadSHA)
+	}
+	// The head commit of an old PR may be gone after a force push
+	if errors.Is(err, ErrNotFound) || isUnprocessable(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) fetchCheckRuns(ctx context.Context, prNumber int, sha string) error {
+	opts := &github.ListCheckRunsOptions{
+		// The default lists only the latest run of each check
+		Filter:      github.String("all"),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	fetch := func(ctx context.Context, page int) (*github.ListCheckRunsResults, *github.Response, error) {
+		opts.Page = page
+		return c.client.Checks.ListCheckRunsForRef(ctx, c.owner, c.repo, sha, opts)
+	}
+	return fetchPages(ctx, c, fetch, func(result *github.ListCheckRunsResults) error {
+		for _, run := range result.CheckRuns {
+			check := c.convertCheckRun(run, prNumber)
+			if err := c.cache.SaveCheckRun(c.GetRepository(), check); err != nil {
+				return fmt.Errorf("saving check run %d: %w", run.GetID(), err)
+			}
+			c.stats.checks.Add(1)
+		}
+		return nil
+	})
+}
+
+// fetchStatuses caches the commit statuses of sha as runs, see statusRuns.
+// A run can span pages, so the statuses are always listed in full rather
+// than page by page with conditional requests.
+func (c *Client) fetchStatuses(ctx context.Context, prNumber int, sha string) error {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	var statuses []*github.RepoStatus
+	for {
+		page, resp, err := c.client.Repositories.ListStatuses(ctx, c.owner, c.repo, sha, opts)
+		if err != nil {
+			return c.handleError(err, resp)
+		}
+		statuses = append(statuses, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	runs := c.statusRuns(statuses, prNumber, sha)
+	if err := c.cache.ReplaceChecks(c.GetRepository(), prNumber, checkKindStatus, sha, runs); err != nil {
+		return fmt.Errorf("saving statuses: %w", err)
+	}
+	c.stats.checks.Add(int64(len(runs)))
+	return nil
+}
+
+func (c *Client) convertCheckRun(run *github.CheckRun, prNumber int) *models.CheckRun {
+	result := &models.CheckRun{
+		ID:         run.GetID(),
+		PullNumber: prNumber,
+		Kind:       checkKindRun,
+		HeadSHA:    run.GetHeadSHA(),
+		Name:       run.GetName(),
+		Status:     run.GetStatus(),
+		Conclusion: run.GetConclusion(),
+		App:        run.GetApp().GetSlug(),
+	}
+
+	if run.StartedAt != nil {
+		result.StartedAt = &run.StartedAt.Time
+	}
+	if run.CompletedAt != nil {
+		result.CompletedAt = &run.CompletedAt.Time
+	}
+
+	return result
+}
+
+// statusRuns records commit statuses like check runs. Statuses cannot be
+// updated, so CI services post a pending status when a job starts and
+// another when it ends; each run of a context starts with its first pending
+// status and is completed by the next status in any other state. A run
+// keeps the ID of the status that started it.
+func (c *Client) statusRuns(statuses []*github.RepoStatus, prNumber int, sha string) []*models.CheckRun {
+	// GitHub lists the newest statuses first
+	statuses = slices.Clone(statuses)
+	slices.SortStableFunc(statuses, func(a, b *github.RepoStatus) int {
+		return cmp.Or(a.GetCreatedAt().Compare(b.GetCreatedAt().Time), cmp.Compare(a.GetID(), b.GetID()))
+	})
+
+	var runs []*models.CheckRun
+	running := make(map[string]*models.CheckRun)
+	for _, status := range statuses {
+		name := status.GetContext()
+		var createdAt *time.Time
+		if status.CreatedAt != nil {
+			createdAt = &status.CreatedAt.Time
+		}
+
+		run, ok := running[name]
+		if !ok {
+			run = &models.CheckRun{
+				ID:         status.GetID(),
+				PullNumber: prNumber,
+				Kind:       checkKindStatus,
+				HeadSHA:    sha,
+				Name:       name,
+				Status:     "in_progress",
+				App:        status.GetCreator().GetLogin(),
+				StartedAt:  createdAt,
+			}
+			running[name] = run
+			runs = append(runs, run)
+		}
+
+		if state := status.GetState(); state != "pending" {
+			run.Status = "completed"
+			run.Conclusion = state
+			run.CompletedAt = createdAt
+			delete(running, name)
+		}
+	}
+
+	return runs
+}
+
+// isUnprocessable reports whether GitHub rejected a request as invalid,
+// as it does for the statuses of a commit that no longer exists.
+func isUnprocessable(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusUnprocessableEntity
+}
@@ -0,0 +1,68 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+// statusesFixture lists a Jenkins job that failed and was run again, newest
+// first as GitHub lists statuses.
+const statusesFixture = `[{
+	"id": 4, "context": "ci/jenkins", "state": "success", "created_at": "2024-01-02T09:25:00Z", "creator": {"login": "jenkins"}
+}, {
+	"id": 3, "context": "ci/jenkins", "state": "pending", "created_at": "2024-01-02T09:15:00Z", "creator": {"login": "jenkins"}
+}, {
+	"id": 2, "context": "ci/jenkins", "state": "failure", "created_at": "2024-01-02T09:10:00Z", "creator": {"login": "jenkins"}
+}, {
+	"id": 1, "context": "ci/jenkins", "state": "pending", "created_at": "2024-01-02T09:00:00Z", "creator": {"login": "jenkins"}
+}]`
+
+func TestFetchChecksGroupsStatusesIntoRuns(t *testing.T) {
+	client, store := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/owner/repo/commits/bbb222/statuses":
+			_, _ = w.Write([]byte(statusesFixture))
+		case "/repos/owner/repo/commits/bbb222/check-runs":
+			_, _ = w.Write([]byte(`{"total_count": 0, "check_runs": []}`))
+		default:
+			t.Errorf("requested %s", r.URL.Path)
+		}
+	}))
+	if err := store.SavePullRequest("owner/repo", &models.PullRequest{Number: 1, State: "open", HeadSHA: "bbb222"}); err != nil {
+		t.Fatalf("SavePullRequest() error = %v", err)
+	}
+
+	if err := client.FetchChecks(context.Background(), 1); err != nil {
+		t.Fatalf("FetchChecks() error = %v", err)
+	}
+	checks, err := store.GetChecksByPull("owner/repo", []int{1})
+	if err != nil {
+		t.Fatalf("GetChecksByPull() error = %v", err)
+	}
+
+	at := func(minute int) time.Time { return time.Date(2024, 1, 2, 9, minute, 0, 0, time.UTC) }
+	runs := checks[1]
+	if len(runs) != 2 {
+		t.Fatalf("cached %d runs, want the failed run and its re-run: %+v", len(runs), runs)
+	}
+	for i, want := range []struct {
+		id          int64
+		conclusion  string
+		start, done time.Time
+	}{
+		{1, "failure", at(0), at(10)},
+		{3, "success", at(15), at(25)},
+	} {
+		run := runs[i]
+		if run.ID != want.id || run.Kind != checkKindStatus || run.Name != "ci/jenkins" || run.Status != "completed" ||
+			run.Conclusion != want.conclusion || run.StartedAt == nil || !run.StartedAt.Equal(want.start) ||
+			run.CompletedAt == nil || !run.CompletedAt.Equal(want.done) {
+			t.Errorf("run %d = %+v, want %s from %v to %v", i, run, want.conclusion, want.start, want.done)
+		}
+	}
+}
@@ -2,9 +2,13 @@ package github
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/bonyuta0204/pr-analyzer/internal/cache"
@@ -15,11 +19,19 @@ import (
 )
 
 type Client struct {
-	client *github.Client
-	cache  *cache.Store
-	config *config.Config
-	owner  string
-	repo   string
+	client     *github.Client
+	httpClient *http.Client
+	graphqlURL string
+	fetcher    fetcher
+	cache      *cache.Store
+	config     *config.Config
+	limiter    *rateLimiter
+	tokenNames []string
+	stats      *fetchStats
+	refetch    bool
+	patches    bool
+	owner      string
+	repo       string
 }
 
 func NewClient(cfg *config.Config, store *cache.Store, repoPath string) (*Client, error) {
@@ -28,11 +40,29 @@ func NewClient(cfg *config.Config, store *cache.Store, repoPath string) (*Client
 		return nil, fmt.Errorf("invalid repository format: %s (expected owner/repo)", repoPath)
 	}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: cfg.GitHub.Token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+	transport, err := newTransport(cfg.GitHub)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, tokenNames, err := credentials(cfg.GitHub, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := newPooledRateLimiter(cfg.Fetch.RateLimitBuffer, max(len(tokens), 1))
+	// Validators are looked up once the credential of a request is chosen
+	retrier := &retryTransport{
+		base: &rateLimitTransport{
+			base:        &etagTransport{base: transport, store: store},
+			limiter:     limiter,
+			tokens:      tokens,
+			credentials: credentialIDs(cfg.GitHub),
+		},
+		maxAttempts: cfg.Fetch.MaxRetries + 1,
+		maxElapsed:  cfg.Fetch.RetryMaxElapsed,
+	}
+	tc := &http.Client{Transport: retrier}
 
 	githubClient := github.NewClient(tc)
 	if cfg.GitHub.APIURL != "" && cfg.GitHub.APIURL != "https://api.github.com" {
@@ -40,94 +70,411 @@ func NewClient(cfg *config.Config, store *cache.Store, repoPath string) (*Client
 		if !strings.HasSuffix(baseURL, "/") {
 			baseURL += "/"
 		}
-		var err error
 		githubClient.BaseURL, err = githubClient.BaseURL.Parse(baseURL)
 		if err != nil {
 			return nil, fmt.Errorf("parsing GitHub API URL: %w", err)
 		}
 	}
+	if upload := uploadURL(cfg.GitHub); upload != "" {
+		if !strings.HasSuffix(upload, "/") {
+			upload += "/"
+		}
+		githubClient.UploadURL, err = githubClient.UploadURL.Parse(upload)
+		if err != nil {
+			return nil, fmt.Errorf("parsing GitHub upload URL: %w", err)
+		}
+	}
+
+	client := &Client{
+		client:     githubClient,
+		httpClient: tc,
+		graphqlURL: graphqlURL(cfg.GitHub.APIURL),
+		cache:      store,
+		config:     cfg,
+		limiter:    limiter,
+		tokenNames: tokenNames,
+		stats:      &fetchStats{},
+		owner:      parts[0],
+		repo:       parts[1],
+	}
+	retrier.onRetry = func() { client.stats.retries.Add(1) }
 
-	return &Client{
-		client: githubClient,
-		cache:  store,
-		config: cfg,
-		owner:  parts[0],
-		repo:   parts[1],
-	}, nil
+	client.fetcher, err = newFetcher(client, cfg.Fetch.API)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// credentials returns a token source for each configured credential along
+// with a name for it that is safe to print. Several personal tokens form a
+// pool that the rate limiter rotates through.
+func credentials(cfg config.GitHubConfig, transport http.RoundTripper) ([]oauth2.TokenSource, []string, error) {
+	if cfg.App.Enabled() {
+		src, err := newAppTokenSource(cfg, &http.Client{Transport: transport, Timeout: 30 * time.Second})
+		if err != nil {
+			return nil, nil, err
+		}
+		return []oauth2.TokenSource{src}, []string{fmt.Sprintf("app %d", cfg.App.AppID)}, nil
+	}
+
+	var sources []oauth2.TokenSource
+	var names []string
+	for _, token := range cfg.AllTokens() {
+		sources = append(sources, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+		names = append(names, "…"+token[max(len(token)-4, 0):])
+	}
+	return sources, names, nil
+}
+
+// credentialIDs identifies each credential returned by credentials without
+// revealing it. Unlike the printable names they do not collide, and an app
+// keeps its ID while its installation tokens expire.
+func credentialIDs(cfg config.GitHubConfig) []string {
+	if cfg.App.Enabled() {
+		return []string{fmt.Sprintf("app:%d:%d", cfg.App.AppID, cfg.App.InstallationID)}
+	}
+
+	var ids []string
+	for _, token := range cfg.AllTokens() {
+		sum := sha256.Sum256([]byte(token))
+		ids = append(ids, "token:"+hex.EncodeToString(sum[:8]))
+	}
+	return ids
+}
+
+// OnRateLimitPause registers fn to be called whenever fetching pauses for the rate limit.
+func (c *Client) OnRateLimitPause(fn PauseFunc) {
+	c.limiter.setPauseFunc(fn)
 }
 
 func (c *Client) GetRepository() string {
 	return c.owner + "/" + c.repo
 }
 
-func (c *Client) FetchPullRequests(ctx context.Context, since time.Time, prNumber int, limit int) error {
-	if prNumber > 0 {
-		return c.fetchSinglePR(ctx, prNumber)
+// FetchOptions controls which pull requests are fetched.
+type FetchOptions struct {
+	Since    time.Time
+	PRNumber int
+	Limit    int
+	All      bool
+	Refetch  bool
+	Resume   bool
+	// Patches fetches the diff of each file, which only REST provides
+	Patches bool
+}
+
+// FetchResult summarizes what a fetch changed in the cache.
+type FetchResult struct {
+	New       int
+	Changed   int
+	Unchanged int
+	Reviews   int
+	Comments  int
+	Files     int
+	Commits   int
+	// Threads counts the cached review thread states
+	Threads int
+	// Checks counts the cached check runs and commit statuses
+	Checks int
+	// TimelineEvents counts the cached review requests, label changes and
+	// other timeline events
+	TimelineEvents int
+	// ETagHits counts detail pages answered with 304 Not Modified and
+	// ETagMisses those that had to be downloaded again.
+	ETagHits   int
+	ETagMisses int
+	// Retries counts requests repeated after a transient error
+	Retries int
+	// Tokens reports the requests sent with each credential
+	Tokens []TokenUsage
+}
+
+// fetchStats counts fetch outcomes across concurrent workers.
+type fetchStats struct {
+	newPRs         atomic.Int64
+	changedPRs     atomic.Int64
+	unchangedPRs   atomic.Int64
+	reviews        atomic.Int64
+	comments       atomic.Int64
+	files          atomic.Int64
+	threads        atomic.Int64
+	commits        atomic.Int64
+	checks         atomic.Int64
+	timelineEvents atomic.Int64
+	etagHits       atomic.Int64
+	etagMisses     atomic.Int64
+	retries        atomic.Int64
+}
+
+func (s *fetchStats) result() *FetchResult {
+	return &FetchResult{
+		New:            int(s.newPRs.Load()),
+		Changed:        int(s.changedPRs.Load()),
+		Unchanged:      int(s.unchangedPRs.Load()),
+		Reviews:        int(s.reviews.Load()),
+		Comments:       int(s.comments.Load()),
+		Files:          int(s.files.Load()),
+		Threads:        int(s.threads.Load()),
+		Commits:        int(s.commits.Load()),
+		Checks:         int(s.checks.Load()),
+		TimelineEvents: int(s.timelineEvents.Load()),
+		ETagHits:       int(s.etagHits.Load()),
+		ETagMisses:     int(s.etagMisses.Load()),
+		Retries:        int(s.retries.Load()),
+	}
+}
+
+// result summarizes the current fetch.
+func (c *Client) result() *FetchResult {
+	result := c.stats.result()
+	result.Tokens = c.limiter.usage(c.tokenNames)
+	return result
+}
+
+// listStop describes why listing pull requests ended.
+type listStop int
+
+const (
+	listExhausted listStop = iota
+	listReachedLimit
+	listReachedCutoff
+)
+
+func (c *Client) FetchPullRequests(ctx context.Context, fetchOpts FetchOptions) (*FetchResult, error) {
+	c.stats = &fetchStats{}
+	c.refetch = fetchOpts.Refetch
+	c.patches = fetchOpts.Patches
+	c.limiter.resetUsage()
+
+	if fetchOpts.PRNumber > 0 {
+		if err := c.fetchSinglePR(ctx, fetchOpts.PRNumber, fetchOpts.Refetch); err != nil {
+			return nil, err
+		}
+		if err := c.fetchMissingPatches(ctx, []int{fetchOpts.PRNumber}); err != nil {
+			return nil, err
+		}
+		return c.result(), nil
+	}
+
+	meta, err := c.cache.GetSyncMetadata(c.GetRepository())
+	if err != nil {
+		return nil, fmt.Errorf("reading sync metadata: %w", err)
 	}
 
+	run := newSyncRun(fetchOpts, meta)
+	if fetchOpts.Resume {
+		cp, err := c.cache.GetFetchCheckpoint(c.GetRepository())
+		if err != nil {
+			return nil, fmt.Errorf("reading fetch checkpoint: %w", err)
+		}
+		if cp == nil {
+			return nil, fmt.Errorf("%w for %s", ErrNoCheckpoint, c.GetRepository())
+		}
+		run = resumeSyncRun(cp)
+		c.refetch = run.refetch
+	}
+
+	// Details for many PRs are fetched in parallel while the listing continues
+	pool := newWorkerPool(ctx, c.config.Fetch.Concurrency, func(ctx context.Context, number int) error {
+		if err := c.fetcher.details(ctx, number); err != nil {
+			return fmt.Errorf("fetching details for PR %d: %w", number, err)
+		}
+		run.complete(number)
+		return nil
+	})
+
+	stop, err := c.resumeAndList(ctx, run, pool)
+	if err != nil {
+		pool.fail(err)
+	}
+	if err := pool.Wait(); err != nil {
+		// Flush the checkpoint even when ctx was cancelled by an interrupt
+		if saveErr := c.cache.SaveFetchCheckpoint(run.checkpoint(c.GetRepository())); saveErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("saving fetch checkpoint: %w", saveErr))
+		}
+		return nil, err
+	}
+
+	if err := c.cache.DeleteFetchCheckpoint(c.GetRepository()); err != nil {
+		return nil, fmt.Errorf("deleting fetch checkpoint: %w", err)
+	}
+	if err := c.updateSyncMetadata(meta, run, stop); err != nil {
+		return nil, fmt.Errorf("saving sync metadata: %w", err)
+	}
+
+	missing, err := c.cache.GetPullsMissingPatches(c.GetRepository())
+	if err != nil {
+		return nil, fmt.Errorf("reading cached PRs: %w", err)
+	}
+	if err := c.fetchMissingPatches(ctx, missing); err != nil {
+		return nil, err
+	}
+
+	return c.result(), nil
+}
+
+// fetchMissingPatches fetches the files of the given PRs again with REST
+// when patches are wanted but the PRs' files were listed with GraphQL.
+// Unchanged PRs are not fetched otherwise, so their patches would stay
+// missing.
+func (c *Client) fetchMissingPatches(ctx context.Context, numbers []int) error {
+	if !c.patches {
+		return nil
+	}
+
+	pool := newWorkerPool(ctx, c.config.Fetch.Concurrency, func(ctx context.Context, number int) error {
+		missing, err := c.cache.GetPullMissingPatches(c.GetRepository(), number)
+		if err != nil {
+			return fmt.Errorf("reading cached PR %d: %w", number, err)
+		}
+		if !missing {
+			return nil
+		}
+		if err := c.FetchFiles(ctx, number); err != nil {
+			return fmt.Errorf("fetching files for PR %d: %w", number, err)
+		}
+		return nil
+	})
+	for _, number := range numbers {
+		if err := pool.Submit(number); err != nil {
+			break
+		}
+	}
+	return pool.Wait()
+}
+
+// resumeAndList requeues details left pending by an interrupted run and
+// then continues listing from the run's current page.
+func (c *Client) resumeAndList(ctx context.Context, run *syncRun, pool *workerPool) (listStop, error) {
+	for _, number := range run.pendingNumbers() {
+		c.stats.changedPRs.Add(1)
+		if err := pool.Submit(number); err != nil {
+			return listExhausted, err
+		}
+	}
+
+	return c.fetcher.list(ctx, run, pool)
+}
+
+// updateSyncMetadata records the sync. LastSyncAt only advances when the
+// listing left no gap back to the previous sync, so that a run truncated by
+// --limit is picked up again by the next incremental run.
+func (c *Client) updateSyncMetadata(prev *models.SyncMetadata, run *syncRun, stop listStop) error {
+	meta := &models.SyncMetadata{
+		Repo:       c.GetRepository(),
+		LastSyncAt: run.startedAt,
+	}
+
+	switch {
+	case stop == listExhausted && run.unbounded:
+		meta.Complete = true
+	case stop == listReachedCutoff && run.syncCutoff && prev != nil:
+		meta.Complete = prev.Complete
+	case prev != nil:
+		meta.LastSyncAt = prev.LastSyncAt
+		meta.Complete = prev.Complete
+	}
+
+	total, open, closed, err := c.cache.CountPullRequests(c.GetRepository())
+	if err != nil {
+		return err
+	}
+	meta.TotalPRs = total
+	meta.OpenPRs = open
+	meta.ClosedPRs = closed
+
+	return c.cache.SaveSyncMetadata(meta)
+}
+
+// listPullRequests pages through PRs, saving each one and queueing details
+// for new or changed PRs on pool until the limit or the cutoff is reached.
+// A checkpoint is written after every page.
+func (c *Client) listPullRequests(ctx context.Context, run *syncRun, pool *workerPool) (listStop, error) {
 	opts := &github.PullRequestListOptions{
 		State:     "all",
 		Sort:      "updated",
 		Direction: "desc",
 		ListOptions: github.ListOptions{
 			PerPage: c.config.Fetch.BatchSize,
+			Page:    run.currentPage(),
 		},
 	}
 
-	totalFetched := 0
 	for {
 		prs, resp, err := c.client.PullRequests.List(ctx, c.owner, c.repo, opts)
 		if err != nil {
-			return c.handleError(err, resp.Response)
+			return listExhausted, c.handleError(err, resp)
 		}
 
 		for _, pr := range prs {
-			// Check if we've reached the limit
-			if limit > 0 && totalFetched >= limit {
-				return nil
-			}
-			// Skip if PR hasn't been updated since the given time
-			if !since.IsZero() && pr.UpdatedAt.Before(since) {
-				// Since results are sorted by updated desc, we can stop here
-				return nil
+			if stop, ok := run.admit(pr.GetUpdatedAt().Time); !ok {
+				return stop, nil
 			}
 
-			pullRequest := c.convertPullRequest(pr)
-			if err := c.cache.SavePullRequest(pullRequest); err != nil {
-				return fmt.Errorf("saving PR %d: %w", *pr.Number, err)
+			run.enqueue(pr.GetNumber())
+			changed, err := c.savePullRequest(c.convertPullRequest(pr), run.refetch)
+			if err != nil {
+				return listExhausted, err
 			}
 
-			// Fetch additional data for each PR
-			if err := c.fetchPRDetails(ctx, *pr.Number); err != nil {
-				return fmt.Errorf("fetching details for PR %d: %w", *pr.Number, err)
+			// Fetch additional data only for PRs that changed since they were cached
+			if !changed {
+				run.complete(pr.GetNumber())
+				continue
+			}
+			if err := pool.Submit(pr.GetNumber()); err != nil {
+				return listExhausted, err
 			}
-
-			totalFetched++
 		}
 
 		if resp.NextPage == 0 {
-			break
+			return listExhausted, nil
 		}
 		opts.Page = resp.NextPage
+
+		run.nextPage(resp.NextPage)
+		if err := c.cache.SaveFetchCheckpoint(run.checkpoint(c.GetRepository())); err != nil {
+			return listExhausted, fmt.Errorf("saving fetch checkpoint: %w", err)
+		}
 	}
+}
 
-	// Update sync metadata
-	meta := &models.SyncMetadata{
-		Repo:       c.GetRepository(),
-		LastSyncAt: time.Now(),
+// savePullRequest caches pr and reports whether its details need fetching,
+// classifying it as new, changed or unchanged.
+func (c *Client) savePullRequest(pullRequest *models.PullRequest, refetch bool) (bool, error) {
+	cachedUpdatedAt, err := c.cache.GetPullUpdatedAt(c.GetRepository(), pullRequest.Number)
+	if err != nil {
+		return false, fmt.Errorf("reading cached PR %d: %w", pullRequest.Number, err)
+	}
+
+	if err := c.cache.SavePullRequest(c.GetRepository(), pullRequest); err != nil {
+		return false, fmt.Errorf("saving PR %d: %w", pullRequest.Number, err)
+	}
+
+	switch {
+	case cachedUpdatedAt == nil:
+		c.stats.newPRs.Add(1)
+		return true, nil
+	case !cachedUpdatedAt.Equal(pullRequest.UpdatedAt):
+		c.stats.changedPRs.Add(1)
+		return true, nil
+	default:
+		c.stats.unchangedPRs.Add(1)
+		return refetch, nil
 	}
-	return c.cache.SaveSyncMetadata(meta)
 }
 
-func (c *Client) fetchSinglePR(ctx context.Context, number int) error {
+func (c *Client) fetchSinglePR(ctx context.Context, number int, refetch bool) error {
 	pr, resp, err := c.client.PullRequests.Get(ctx, c.owner, c.repo, number)
 	if err != nil {
-		return c.handleError(err, resp.Response)
+		return c.handleError(err, resp)
 	}
 
-	pullRequest := c.convertPullRequest(pr)
-	if err := c.cache.SavePullRequest(pullRequest); err != nil {
-		return fmt.Errorf("saving PR %d: %w", number, err)
+	changed, err := c.savePullRequest(c.convertPullRequest(pr), refetch)
+	if err != nil || !changed {
+		return err
 	}
 
 	return c.fetchPRDetails(ctx, number)
@@ -144,33 +491,78 @@ func (c *Client) fetchPRDetails(ctx context.Context, number int) error {
 		return fmt.Errorf("fetching comments: %w", err)
 	}
 
+	// Fetch the state of review threads
+	if err := c.FetchReviewThreads(ctx, number); err != nil {
+		return fmt.Errorf("fetching review threads: %w", err)
+	}
+
 	// Fetch files
 	if err := c.FetchFiles(ctx, number); err != nil {
 		return fmt.Errorf("fetching files: %w", err)
 	}
 
+	// Fetch commits
+	if err := c.FetchCommits(ctx, number); err != nil {
+		return fmt.Errorf("fetching commits: %w", err)
+	}
+
+	// Fetch CI results of the head commit
+	if err := c.FetchChecks(ctx, number); err != nil {
+		return fmt.Errorf("fetching CI checks: %w", err)
+	}
+
+	// Fetch timeline events
+	if err := c.FetchTimeline(ctx, number); err != nil {
+		return fmt.Errorf("fetching timeline: %w", err)
+	}
+
 	return nil
 }
 
 func (c *Client) convertPullRequest(pr *github.PullRequest) *models.PullRequest {
 	result := &models.PullRequest{
-		ID:        pr.GetID(),
-		Number:    pr.GetNumber(),
-		Title:     pr.GetTitle(),
-		State:     pr.GetState(),
-		CreatedAt: pr.GetCreatedAt().Time,
-		UpdatedAt: pr.GetUpdatedAt().Time,
+		ID:                pr.GetID(),
+		Number:            pr.GetNumber(),
+		Title:             pr.GetTitle(),
+		Body:              pr.GetBody(),
+		State:             pr.GetState(),
+		Draft:             pr.GetDraft(),
+		AuthorAssociation: pr.GetAuthorAssociation(),
+		Milestone:         pr.GetMilestone().GetTitle(),
+		CreatedAt:         pr.GetCreatedAt().Time,
+		UpdatedAt:         pr.GetUpdatedAt().Time,
+		BaseRef:           pr.GetBase().GetRef(),
+		BaseSHA:           pr.GetBase().GetSHA(),
+		HeadRef:           pr.GetHead().GetRef(),
+		HeadSHA:           pr.GetHead().GetSHA(),
 		Stats: models.PullRequestStats{
 			Additions:      pr.GetAdditions(),
 			Deletions:      pr.GetDeletions(),
 			ChangedFiles:   pr.GetChangedFiles(),
 			Comments:       pr.GetComments(),
 			ReviewComments: pr.GetReviewComments(),
+			// Only the PR itself reports this; FetchCommits adds it otherwise
+			Commits: pr.GetCommits(),
 		},
 	}
 
+	if pr.ClosedAt != nil {
+		result.ClosedAt = &pr.ClosedAt.Time
+	}
+
+	// Unmerged PRs report the SHA of a test merge, which GraphQL leaves out
 	if pr.MergedAt != nil {
 		result.MergedAt = &pr.MergedAt.Time
+		result.MergeCommitSHA = pr.GetMergeCommitSHA()
+	}
+
+	// The listing leaves out who merged a PR; FetchTimeline adds it from the
+	// merged event
+	if pr.MergedBy != nil {
+		result.MergedBy = &models.User{
+			Login: pr.MergedBy.GetLogin(),
+			Type:  pr.MergedBy.GetType(),
+		}
 	}
 
 	// Author
@@ -197,6 +589,10 @@ func (c *Client) convertPullRequest(pr *github.PullRequest) *models.PullRequest
 		})
 	}
 
+	for _, team := range pr.RequestedTeams {
+		result.RequestedTeams = append(result.RequestedTeams, team.GetSlug())
+	}
+
 	// Labels
 	for _, label := range pr.Labels {
 		result.Labels = append(result.Labels, models.Label{
@@ -207,18 +603,3 @@ func (c *Client) convertPullRequest(pr *github.PullRequest) *models.PullRequest
 
 	return result
 }
-
-func (c *Client) handleError(err error, resp *http.Response) error {
-	if resp != nil {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return fmt.Errorf("GitHub authentication failed: please check your token")
-		}
-		if resp.StatusCode == http.StatusForbidden {
-			return fmt.Errorf("GitHub API rate limit exceeded or forbidden access")
-		}
-		if resp.StatusCode == http.StatusNotFound {
-			return fmt.Errorf("repository not found: %s/%s", c.owner, c.repo)
-		}
-	}
-	return fmt.Errorf("GitHub API error: %w", err)
-}
@@ -0,0 +1,246 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/cache"
+	"github.com/bonyuta0204/pr-analyzer/internal/config"
+)
+
+// fakeGitHub serves the REST endpoints used during a fetch for owner/repo.
+type fakeGitHub struct {
+	pulls []map[string]any
+	// failPaths answers these paths with 404 Not Found
+	failPaths map[string]bool
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if f.failPaths[r.URL.Path] {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+		return
+	}
+
+	var body any = []any{}
+	switch {
+	case r.URL.Path == "/repos/owner/repo/pulls":
+		body = f.pulls
+	case r.URL.Path == "/repos/owner/repo/pulls/1/reviews":
+		body = []map[string]any{{"id": 10, "state": "APPROVED", "user": map[string]any{"login": "bob"}}}
+	case r.URL.Path == "/repos/owner/repo/pulls/1/files":
+		body = []map[string]any{{"filename": "main.go", "status": "modified", "additions": 1}}
+	}
+
+	data, _ := json.Marshal(body)
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write(data)
+}
+
+func newFakePull(number int, updatedAt time.Time) map[string]any {
+	return map[string]any{
+		"id":         number * 100,
+		"number":     number,
+		"title":      fmt.Sprintf("PR %d", number),
+		"state":      "open",
+		"user":       map[string]any{"login": "alice", "type": "User"},
+		"created_at": updatedAt.Add(-time.Hour).Format(time.RFC3339),
+		"updated_at": updatedAt.Format(time.RFC3339),
+	}
+}
+
+func newTestClient(t *testing.T, handler http.Handler) (*Client, *cache.Store) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	store, err := cache.NewStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.GitHub.Token = "test-token"
+	cfg.GitHub.APIURL = server.URL
+
+	client, err := NewClient(cfg, store, "owner/repo")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client, store
+}
+
+func TestFetchPullRequestsIsIncremental(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fake := &fakeGitHub{pulls: []map[string]any{newFakePull(2, updatedAt), newFakePull(1, updatedAt)}}
+	client, store := newTestClient(t, fake)
+	ctx := context.Background()
+
+	result, err := client.FetchPullRequests(ctx, FetchOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("first FetchPullRequests() error = %v", err)
+	}
+	if result.New != 2 || result.Reviews != 1 || result.Files != 1 {
+		t.Errorf("first fetch = %+v, want 2 new PRs with 1 review and 1 file", result)
+	}
+
+	// Nothing changed since the last sync, so listing stops immediately
+	result, err = client.FetchPullRequests(ctx, FetchOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("second FetchPullRequests() error = %v", err)
+	}
+	if result.New+result.Changed+result.Unchanged != 0 {
+		t.Errorf("second fetch = %+v, want no PRs listed", result)
+	}
+
+	// An explicit --since lists past the last sync but skips unchanged details
+	fake.pulls[0] = newFakePull(2, time.Now().Add(time.Minute).Truncate(time.Second))
+	result, err = client.FetchPullRequests(ctx, FetchOptions{Since: updatedAt.Add(-time.Minute), Limit: 100})
+	if err != nil {
+		t.Fatalf("third FetchPullRequests() error = %v", err)
+	}
+	if result.Changed != 1 || result.Unchanged != 1 || result.Reviews != 0 {
+		t.Errorf("third fetch = %+v, want 1 changed and 1 unchanged PR", result)
+	}
+
+	meta, err := store.GetSyncMetadata("owner/repo")
+	if err != nil || meta == nil {
+		t.Fatalf("GetSyncMetadata() = %v, %v", meta, err)
+	}
+	if meta.TotalPRs != 2 || !meta.Complete {
+		t.Errorf("sync metadata = %+v, want 2 PRs and a complete sync", meta)
+	}
+}
+
+func TestFetchPullRequestsResumesAfterFailure(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fake := &fakeGitHub{
+		pulls:     []map[string]any{newFakePull(2, updatedAt), newFakePull(1, updatedAt)},
+		failPaths: map[string]bool{"/repos/owner/repo/pulls/1/reviews": true},
+	}
+	client, store := newTestClient(t, fake)
+	ctx := context.Background()
+
+	if _, err := client.FetchPullRequests(ctx, FetchOptions{Limit: 100}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("FetchPullRequests() error = %v, want not found fetching reviews", err)
+	}
+
+	cp, err := store.GetFetchCheckpoint("owner/repo")
+	if err != nil || cp == nil {
+		t.Fatalf("GetFetchCheckpoint() = %v, %v; want a checkpoint", cp, err)
+	}
+	if !slices.Contains(cp.Pending, 1) {
+		t.Errorf("checkpoint pending = %v, want it to include PR 1", cp.Pending)
+	}
+
+	fake.failPaths = nil
+	result, err := client.FetchPullRequests(ctx, FetchOptions{Limit: 100, Resume: true})
+	if err != nil {
+		t.Fatalf("resumed FetchPullRequests() error = %v", err)
+	}
+	if result.Reviews != 1 {
+		t.Errorf("resumed fetch = %+v, want the pending review fetched", result)
+	}
+
+	if cp, _ := store.GetFetchCheckpoint("owner/repo"); cp != nil {
+		t.Errorf("checkpoint = %+v after a successful resume, want none", cp)
+	}
+	if _, err := client.FetchPullRequests(ctx, FetchOptions{Resume: true}); !errors.Is(err, ErrNoCheckpoint) {
+		t.Errorf("FetchPullRequests(Resume) without checkpoint error = %v, want ErrNoCheckpoint", err)
+	}
+}
+
+func TestFetchPullRequestsUsesETags(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fake := &fakeGitHub{pulls: []map[string]any{newFakePull(1, updatedAt)}}
+	client, store := newTestClient(t, fake)
+	ctx := context.Background()
+
+	result, err := client.FetchPullRequests(ctx, FetchOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("first FetchPullRequests() error = %v", err)
+	}
+	if result.ETagHits != 0 || result.ETagMisses == 0 {
+		t.Errorf("first fetch = %+v, want only ETag misses", result)
+	}
+
+	fake.pulls[0] = newFakePull(1, time.Now().Add(time.Minute).Truncate(time.Second))
+	result, err = client.FetchPullRequests(ctx, FetchOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("second FetchPullRequests() error = %v", err)
+	}
+	if result.Changed != 1 || result.ETagMisses != 0 || result.ETagHits == 0 || result.Reviews != 0 {
+		t.Errorf("second fetch = %+v, want every detail page answered by its ETag", result)
+	}
+
+	reviews, err := store.GetReviews("owner/repo", 1)
+	if err != nil || len(reviews) != 1 {
+		t.Errorf("GetReviews() = %d reviews, %v; want the cached review kept", len(reviews), err)
+	}
+}
+
+func TestETagsAreKeptPerCredential(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fake := &fakeGitHub{pulls: []map[string]any{newFakePull(1, updatedAt)}}
+	conditional := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional[r.Header.Get("Authorization")]++
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := cache.NewStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	fetch := func(token string) *FetchResult {
+		t.Helper()
+		cfg := config.DefaultConfig()
+		cfg.GitHub.Token = token
+		cfg.GitHub.APIURL = server.URL
+		client, err := NewClient(cfg, store, "owner/repo")
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		result, err := client.FetchPullRequests(context.Background(), FetchOptions{Limit: 100})
+		if err != nil {
+			t.Fatalf("FetchPullRequests() error = %v", err)
+		}
+		return result
+	}
+
+	fetch("token-a")
+	fake.pulls[0] = newFakePull(1, time.Now().Add(time.Minute).Truncate(time.Second))
+	if result := fetch("token-b"); result.Changed != 1 || result.ETagHits != 0 {
+		t.Fatalf("fetch with token-b = %+v, want the changed PR downloaded in full", result)
+	}
+	if n := conditional["Bearer token-b"]; n != 0 {
+		t.Errorf("sent %d conditional requests with token-b before it received any ETag", n)
+	}
+
+	fake.pulls[0] = newFakePull(1, time.Now().Add(2*time.Minute).Truncate(time.Second))
+	if result := fetch("token-b"); result.Changed != 1 || result.ETagHits == 0 {
+		t.Errorf("second fetch with token-b = %+v, want its own ETags replayed", result)
+	}
+}
@@ -29,26 +29,20 @@ func (c *Client) fetchIssueComments(ctx context.Context, prNumber int) error {
 		},
 	}
 
-	for {
-		comments, resp, err := c.client.Issues.ListComments(ctx, c.owner, c.repo, prNumber, opts)
-		if err != nil {
-			return c.handleError(err, resp.Response)
-		}
-
+	fetch := func(ctx context.Context, page int) ([]*github.IssueComment, *github.Response, error) {
+		opts.Page = page
+		return c.client.Issues.ListComments(ctx, c.owner, c.repo, prNumber, opts)
+	}
+	return fetchPages(ctx, c, fetch, func(comments []*github.IssueComment) error {
 		for _, comment := range comments {
 			convertedComment := c.convertIssueComment(comment, prNumber)
-			if err := c.cache.SaveComment(convertedComment); err != nil {
+			if err := c.cache.SaveComment(c.GetRepository(), convertedComment); err != nil {
 				return fmt.Errorf("saving comment %d: %w", comment.GetID(), err)
 			}
+			c.stats.comments.Add(1)
 		}
-
-		if resp.NextPage == 0 {
-			break
-		}
-		opts.Page = resp.NextPage
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (c *Client) fetchReviewComments(ctx context.Context, prNumber int) error {
@@ -58,26 +52,20 @@ func (c *Client) fetchReviewComments(ctx context.Context, prNumber int) error {
 		},
 	}
 
-	for {
-		comments, resp, err := c.client.PullRequests.ListComments(ctx, c.owner, c.repo, prNumber, opts)
-		if err != nil {
-			return c.handleError(err, resp.Response)
-		}
-
+	fetch := func(ctx context.Context, page int) ([]*github.PullRequestComment, *github.Response, error) {
+		opts.Page = page
+		return c.client.PullRequests.ListComments(ctx, c.owner, c.repo, prNumber, opts)
+	}
+	return fetchPages(ctx, c, fetch, func(comments []*github.PullRequestComment) error {
 		for _, comment := range comments {
 			convertedComment := c.convertReviewComment(comment, prNumber)
-			if err := c.cache.SaveComment(convertedComment); err != nil {
+			if err := c.cache.SaveComment(c.GetRepository(), convertedComment); err != nil {
 				return fmt.Errorf("saving review comment %d: %w", comment.GetID(), err)
 			}
+			c.stats.comments.Add(1)
 		}
-
-		if resp.NextPage == 0 {
-			break
-		}
-		opts.Page = resp.NextPage
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (c *Client) convertIssueComment(comment *github.IssueComment, prNumber int) *models.Comment {
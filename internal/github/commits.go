@@ -0,0 +1,124 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+	"github.com/google/go-github/v50/github"
+)
+
+// FetchCommits replaces the cached commits of a PR. Unlike the other
+// details they are always downloaded in full, since a force push can
+// remove commits from any page.
+func (c *Client) FetchCommits(ctx context.Context, prNumber int) error {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	var commits []*models.Commit
+	for {
+		page, resp, err := c.client.PullRequests.ListCommits(ctx, c.owner, c.repo, prNumber, opts)
+		if err != nil {
+			return c.handleError(err, resp)
+		}
+		for _, commit := range page {
+			commits = append(commits, c.convertCommit(commit, prNumber))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if err := c.cache.ReplaceCommits(c.GetRepository(), prNumber, commits); err != nil {
+		return fmt.Errorf("saving commits: %w", err)
+	}
+	c.stats.commits.Add(int64(len(commits)))
+
+	return c.recordCommitCount(ctx, prNumber, len(commits))
+}
+
+// maxListedCommits is the most commits GitHub lists for a PR.
+const maxListedCommits = 250
+
+// recordCommitCount stores the number of commits of a PR in its stats,
+// which the REST listing leaves out. listed is exact below GitHub's limit;
+// beyond it the count comes from the PR itself, unless GraphQL already
+// reported it.
+func (c *Client) recordCommitCount(ctx context.Context, prNumber int, listed int) error {
+	count := listed
+	if listed >= maxListedCommits {
+		cached, err := c.cache.GetPullCommitCount(c.GetRepository(), prNumber)
+		if err != nil {
+			return fmt.Errorf("reading cached PR %d: %w", prNumber, err)
+		}
+		if cached >= maxListedCommits {
+			return nil
+		}
+		pr, resp, err := c.client.PullRequests.Get(ctx, c.owner, c.repo, prNumber)
+		if err != nil {
+			return c.handleError(err, resp)
+		}
+		count = pr.GetCommits()
+	}
+
+	if err := c.cache.SetPullCommitCount(c.GetRepository(), prNumber, count); err != nil {
+		return fmt.Errorf("saving commit count of PR %d: %w", prNumber, err)
+	}
+	return nil
+}
+
+func (c *Client) convertCommit(commit *github.RepositoryCommit, prNumber int) *models.Commit {
+	git := commit.GetCommit()
+	return &models.Commit{
+		SHA:                commit.GetSHA(),
+		PullNumber:         prNumber,
+		Message:            git.GetMessage(),
+		AuthoredAt:         git.GetAuthor().GetDate().Time,
+		CommittedAt:        git.GetCommitter().GetDate().Time,
+		Verified:           git.GetVerification().GetVerified(),
+		VerificationReason: git.GetVerification().GetReason(),
+		Author: models.CommitIdentity{
+			Name:  git.GetAuthor().GetName(),
+			Email: git.GetAuthor().GetEmail(),
+			Login: commit.GetAuthor().GetLogin(),
+		},
+		Committer: models.CommitIdentity{
+			Name:  git.GetCommitter().GetName(),
+			Email: git.GetCommitter().GetEmail(),
+			Login: commit.GetCommitter().GetLogin(),
+		},
+		CoAuthors: parseCoAuthors(git.GetMessage()),
+	}
+}
+
+// parseCoAuthors returns the people credited with Co-authored-by trailers
+// in a commit message, once each.
+func parseCoAuthors(message string) []models.CommitIdentity {
+	var coAuthors []models.CommitIdentity
+	seen := make(map[string]bool)
+
+	for line := range strings.SplitSeq(message, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || !strings.EqualFold(key, "co-authored-by") {
+			continue
+		}
+		address, err := mail.ParseAddress(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		email := strings.ToLower(address.Address)
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		coAuthors = append(coAuthors, models.CommitIdentity{Name: address.Name, Email: address.Address})
+	}
+
+	return coAuthors
+}
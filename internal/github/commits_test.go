@@ -0,0 +1,32 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+func TestParseCoAuthors(t *testing.T) {
+	message := `Add retry budget
+
+Pairing session with the infra team.
+
+Co-authored-by: Bob Smith <bob@example.com>
+co-authored-by: "Carol, Jr." <carol@example.com>
+Co-Authored-By: Bob Smith <BOB@example.com>
+Co-authored-by: not an address
+Signed-off-by: Alice <alice@example.com>`
+
+	want := []models.CommitIdentity{
+		{Name: "Bob Smith", Email: "bob@example.com"},
+		{Name: "Carol, Jr.", Email: "carol@example.com"},
+	}
+	if got := parseCoAuthors(message); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCoAuthors() = %+v, want %+v", got, want)
+	}
+
+	if got := parseCoAuthors("Fix typo"); got != nil {
+		t.Errorf("parseCoAuthors() without trailers = %+v, want none", got)
+	}
+}
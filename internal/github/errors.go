@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// Kinds of GitHub API failures. Errors returned by the client wrap one of
+// them, so callers can tell them apart with errors.Is.
+var (
+	ErrUnauthorized = errors.New("GitHub authentication failed")
+	ErrSSORequired  = errors.New("token is not authorized for the organization's SAML SSO")
+	ErrForbidden    = errors.New("GitHub denied access")
+	ErrNotFound     = errors.New("not found on GitHub")
+	ErrRateLimited  = errors.New("GitHub API rate limit exceeded")
+	ErrServer       = errors.New("GitHub server error")
+	ErrNetwork      = errors.New("could not reach GitHub")
+)
+
+// APIError is a failed GitHub API call.
+type APIError struct {
+	// Kind is one of the Err* values above
+	Kind error
+	// StatusCode is zero when no response was received
+	StatusCode int
+	// SSOURL is where the token can be authorized when Kind is ErrSSORequired
+	SSOURL string
+	Err    error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+func (e *APIError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// handleError classifies a failed API call. resp is nil when the request
+// never got a response, e.g. on a network failure or cancellation.
+func (c *Client) handleError(err error, resp *github.Response) error {
+	// Leave cancellation recognizable so that callers can report an interrupt
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	// Errors from the token source are already classified
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateErr) || errors.As(err, &abuseErr) {
+		return &APIError{Kind: ErrRateLimited, StatusCode: statusCode(resp), Err: err}
+	}
+
+	if resp == nil || resp.Response == nil {
+		return &APIError{Kind: ErrNetwork, Err: err}
+	}
+
+	apiErr = &APIError{StatusCode: resp.StatusCode, Err: err}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		apiErr.Kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden && strings.HasPrefix(resp.Header.Get("X-GitHub-SSO"), "required"):
+		apiErr.Kind = ErrSSORequired
+		apiErr.SSOURL = ssoURL(resp.Header.Get("X-GitHub-SSO"))
+	case resp.StatusCode == http.StatusForbidden:
+		apiErr.Kind = ErrForbidden
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.Kind = ErrRateLimited
+	case resp.StatusCode == http.StatusNotFound:
+		apiErr.Kind = ErrNotFound
+	case resp.StatusCode >= http.StatusInternalServerError:
+		apiErr.Kind = ErrServer
+	default:
+		return fmt.Errorf("GitHub API error: %w", err)
+	}
+	return apiErr
+}
+
+func statusCode(resp *github.Response) int {
+	if resp == nil || resp.Response == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// ssoURL extracts the authorization URL from an X-GitHub-SSO header such
+// as "required; url=https://github.com/orgs/acme/sso?authorization_request=...".
+func ssoURL(header string) string {
+	for _, part := range strings.Split(header, ";") {
+		if url, ok := strings.CutPrefix(strings.TrimSpace(part), "url="); ok {
+			return url
+		}
+	}
+	return ""
+}
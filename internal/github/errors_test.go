@@ -0,0 +1,52 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v50/github"
+)
+
+func TestHandleErrorClassifiesFailures(t *testing.T) {
+	c := &Client{owner: "owner", repo: "repo"}
+	response := func(status int, header http.Header) *github.Response {
+		if header == nil {
+			header = http.Header{}
+		}
+		return &github.Response{Response: &http.Response{StatusCode: status, Header: header}}
+	}
+	apiErr := errors.New("request failed")
+
+	tests := []struct {
+		name string
+		err  error
+		resp *github.Response
+		want error
+	}{
+		{"no response", apiErr, nil, ErrNetwork},
+		{"empty response", apiErr, &github.Response{}, ErrNetwork},
+		{"unauthorized", apiErr, response(http.StatusUnauthorized, nil), ErrUnauthorized},
+		{"sso", apiErr, response(http.StatusForbidden, http.Header{"X-Github-Sso": {"required; url=https://github.com/orgs/acme/sso"}}), ErrSSORequired},
+		{"forbidden", apiErr, response(http.StatusForbidden, nil), ErrForbidden},
+		{"rate limited", &github.RateLimitError{Message: "limit"}, response(http.StatusForbidden, nil), ErrRateLimited},
+		{"not found", apiErr, response(http.StatusNotFound, nil), ErrNotFound},
+		{"server error", apiErr, response(http.StatusBadGateway, nil), ErrServer},
+		{"canceled", context.Canceled, nil, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.handleError(tt.err, tt.resp)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("handleError() = %v, want it to match %v", err, tt.want)
+			}
+		})
+	}
+
+	var sso *APIError
+	err := c.handleError(apiErr, response(http.StatusForbidden, http.Header{"X-Github-Sso": {"required; url=https://github.com/orgs/acme/sso"}}))
+	if !errors.As(err, &sso) || sso.SSOURL != "https://github.com/orgs/acme/sso" {
+		t.Errorf("SSO error = %#v, want the authorization URL", err)
+	}
+}
@@ -0,0 +1,167 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/cache"
+	"github.com/google/go-github/v50/github"
+)
+
+// conditionalKey marks a request context whose requests may be answered
+// with 304 Not Modified. Its value is the repository the request belongs to.
+type conditionalKey struct{}
+
+// withConditionalRequests lets requests made with the returned context be
+// sent conditionally. Callers must handle 304 responses with notModified.
+func withConditionalRequests(ctx context.Context, repo string) context.Context {
+	return context.WithValue(ctx, conditionalKey{}, repo)
+}
+
+// credentialKey carries the ID of the credential a request is authorized
+// with.
+type credentialKey struct{}
+
+func withCredential(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, credentialKey{}, id)
+}
+
+// requestCredential returns the credential ID of a request's context, or ""
+// for a request sent without one.
+func requestCredential(ctx context.Context) string {
+	id, _ := ctx.Value(credentialKey{}).(string)
+	return id
+}
+
+// etagTransport sends If-None-Match and If-Modified-Since for URLs whose
+// validators are cached. GitHub computes ETags per credential, so it runs
+// after a credential has been chosen and only replays validators received
+// with that credential. On a 304 the cached Link header is restored so
+// that pagination continues as if the full page had been returned.
+type etagTransport struct {
+	base  http.RoundTripper
+	store *cache.Store
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Context().Value(conditionalKey{}).(string); !ok || req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	validator, err := t.store.GetHTTPValidator(req.URL.String(), requestCredential(req.Context()))
+	if err != nil {
+		return nil, fmt.Errorf("reading cached ETag: %w", err)
+	}
+	if validator == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	if validator.ETag != "" {
+		req.Header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validator.LastModified)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && validator.Link != "" {
+		resp.Header.Set("Link", validator.Link)
+	}
+	return resp, nil
+}
+
+// fetchPages pages through a list endpoint with conditional requests. fetch
+// requests the given page, 0 for the first, and save caches the rows of
+// each page that changed. A page answered with 304 Not Modified is skipped,
+// since the rows cached from it are still current, and paging continues
+// through the Link header restored by etagTransport.
+func fetchPages[T any](ctx context.Context, c *Client, fetch func(ctx context.Context, page int) (T, *github.Response, error), save func(T) error) error {
+	ctx = c.conditionalContext(ctx)
+	page := 0
+	for {
+		rows, resp, err := fetch(ctx, page)
+		switch {
+		case c.notModified(resp):
+		case err != nil:
+			return c.handleError(err, resp)
+		default:
+			if err := save(rows); err != nil {
+				return err
+			}
+			if err := c.rememberValidators(ctx, resp); err != nil {
+				return err
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		page = resp.NextPage
+	}
+}
+
+// notModified reports whether resp is a 304, meaning the rows cached for
+// the request are still current.
+func (c *Client) notModified(resp *github.Response) bool {
+	if resp == nil || resp.Response == nil || resp.StatusCode != http.StatusNotModified {
+		return false
+	}
+	c.stats.etagHits.Add(1)
+	return true
+}
+
+// rememberValidators stores the validators of a conditional request's
+// response. It is called only after the page's rows have been saved, so a
+// later 304 never refers to rows that are missing from the cache.
+func (c *Client) rememberValidators(ctx context.Context, resp *github.Response) error {
+	repo, ok := ctx.Value(conditionalKey{}).(string)
+	if !ok {
+		return nil
+	}
+	c.stats.etagMisses.Add(1)
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return nil
+	}
+
+	err := c.cache.SaveHTTPValidator(&cache.HTTPValidator{
+		URL:          resp.Request.URL.String(),
+		Credential:   requestCredential(resp.Request.Context()),
+		Repo:         repo,
+		ETag:         etag,
+		LastModified: lastModified,
+		Link:         resp.Header.Get("Link"),
+		UpdatedAt:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("saving ETag: %w", err)
+	}
+	return nil
+}
+
+// unconditionalKey marks a request context whose requests must download
+// rows again even if GitHub reports them unchanged.
+type unconditionalKey struct{}
+
+// withoutConditionalRequests keeps fetchPages from sending requests made
+// with the returned context conditionally.
+func withoutConditionalRequests(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unconditionalKey{}, true)
+}
+
+// conditionalContext returns ctx marked for conditional requests unless the
+// current fetch ignores the cache.
+func (c *Client) conditionalContext(ctx context.Context) context.Context {
+	if c.refetch || ctx.Value(unconditionalKey{}) != nil {
+		return ctx
+	}
+	return withConditionalRequests(ctx, c.GetRepository())
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"context"
+	"fmt"
+)
+
+// Fetch backends, selected with fetch.api in the config or --api.
+const (
+	APIREST    = "rest"
+	APIGraphQL = "graphql"
+)
+
+// fetcher is a backend that fills the cache during a sync. Both backends
+// cache identical records, so the rest of the sync flow does not depend on
+// which one is used.
+type fetcher interface {
+	// list pages through the run's PRs, saving each one and submitting
+	// those that need details to pool.
+	list(ctx context.Context, run *syncRun, pool *workerPool) (listStop, error)
+	// details fetches the details of a PR submitted to the pool, including
+	// PRs left pending by an interrupted run.
+	details(ctx context.Context, number int) error
+}
+
+func newFetcher(c *Client, api string) (fetcher, error) {
+	switch api {
+	case "", APIREST:
+		return &restFetcher{client: c}, nil
+	case APIGraphQL:
+		return newGraphQLFetcher(c), nil
+	default:
+		return nil, fmt.Errorf("unknown API %q (expected %s or %s)", api, APIREST, APIGraphQL)
+	}
+}
+
+// restFetcher lists PRs with the REST API and fetches reviews, comments
+// and files with one request each.
+type restFetcher struct {
+	client *Client
+}
+
+func (f *restFetcher) list(ctx context.Context, run *syncRun, pool *workerPool) (listStop, error) {
+	return f.client.listPullRequests(ctx, run, pool)
+}
+
+func (f *restFetcher) details(ctx context.Context, number int) error {
+	return f.client.fetchPRDetails(ctx, number)
+}
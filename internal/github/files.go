@@ -8,30 +8,43 @@ import (
 	"github.com/google/go-github/v50/github"
 )
 
+// FetchFiles caches the changed files of a PR with their patches.
 func (c *Client) FetchFiles(ctx context.Context, prNumber int) error {
+	missing, err := c.cache.GetPullMissingPatches(c.GetRepository(), prNumber)
+	if err != nil {
+		return fmt.Errorf("reading cached PR %d: %w", prNumber, err)
+	}
+	// Pages unchanged since they were last fetched may have been replaced
+	// by files listed with GraphQL, without patches
+	if missing {
+		ctx = withoutConditionalRequests(ctx)
+	}
+
 	opts := &github.ListOptions{
 		PerPage: 100,
 	}
 
-	for {
-		files, resp, err := c.client.PullRequests.ListFiles(ctx, c.owner, c.repo, prNumber, opts)
-		if err != nil {
-			return c.handleError(err, resp.Response)
-		}
-
+	fetch := func(ctx context.Context, page int) ([]*github.CommitFile, *github.Response, error) {
+		opts.Page = page
+		return c.client.PullRequests.ListFiles(ctx, c.owner, c.repo, prNumber, opts)
+	}
+	err = fetchPages(ctx, c, fetch, func(files []*github.CommitFile) error {
 		for _, file := range files {
 			f := c.convertFile(file, prNumber)
-			if err := c.cache.SaveFile(f); err != nil {
+			if err := c.cache.SaveFile(c.GetRepository(), f); err != nil {
 				return fmt.Errorf("saving file %s: %w", file.GetFilename(), err)
 			}
+			c.stats.files.Add(1)
 		}
-
-		if resp.NextPage == 0 {
-			break
-		}
-		opts.Page = resp.NextPage
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	if err := c.cache.SetPullMissingPatches(c.GetRepository(), prNumber, false); err != nil {
+		return fmt.Errorf("saving PR %d: %w", prNumber, err)
+	}
 	return nil
 }
 
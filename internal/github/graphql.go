@@ -0,0 +1,274 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// graphqlURL derives the GraphQL endpoint from the REST API URL. GitHub
+// Enterprise serves REST under /api/v3 and GraphQL under /api/graphql.
+func graphqlURL(apiURL string) string {
+	base := strings.TrimSuffix(apiURL, "/")
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	if strings.HasSuffix(base, "/api/v3") {
+		return strings.TrimSuffix(base, "/v3") + "/graphql"
+	}
+	return base + "/graphql"
+}
+
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphqlErrors are the errors reported in the body of a GraphQL response.
+type graphqlErrors []graphqlError
+
+func (e graphqlErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e graphqlErrors) hasType(typ string) bool {
+	for _, err := range e {
+		if err.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// graphql runs query and decodes its data into out. Queries rejected for
+// the rate limit are retried once the limiter has waited for the reset.
+func (c *Client) graphql(ctx context.Context, query string, variables map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("marshaling GraphQL query: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		errs, err := c.doGraphQL(ctx, body, out)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(errs) == 0:
+			return nil
+		case errs.hasType("RATE_LIMITED") && attempt < maxRateLimitRetries:
+			continue
+		case errs.hasType("RATE_LIMITED"):
+			return &APIError{Kind: ErrRateLimited, StatusCode: http.StatusOK, Err: errs}
+		case errs.hasType("NOT_FOUND"):
+			return &APIError{Kind: ErrNotFound, StatusCode: http.StatusOK, Err: errs}
+		case errs.hasType("FORBIDDEN") && strings.Contains(errs.Error(), "SAML"):
+			return &APIError{Kind: ErrSSORequired, StatusCode: http.StatusOK, Err: errs}
+		case errs.hasType("FORBIDDEN"):
+			return &APIError{Kind: ErrForbidden, StatusCode: http.StatusOK, Err: errs}
+		default:
+			return fmt.Errorf("GitHub GraphQL error: %w", errs)
+		}
+	}
+}
+
+func (c *Client) doGraphQL(ctx context.Context, body []byte, out any) (graphqlErrors, error) {
+	// Queries only read, so they may be retried like GETs
+	req, err := http.NewRequestWithContext(withIdempotentRequests(ctx), http.MethodPost, c.graphqlURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating GraphQL request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, c.handleError(err, nil)
+	}
+	defer resp.Body.Close()
+
+	if err := github.CheckResponse(resp); err != nil {
+		return nil, c.handleError(err, &github.Response{Response: resp})
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors graphqlErrors   `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding GraphQL response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return result.Errors, nil
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return nil, fmt.Errorf("decoding GraphQL data: %w", err)
+	}
+	return nil, nil
+}
+
+// graphqlPageSize bounds the PRs per GraphQL page. Each PR pulls up to 100
+// review threads of 100 comments, which keeps a page well below GitHub's
+// limit of 500,000 nodes per query.
+const graphqlPageSize = 25
+
+// graphqlFetcher lists PRs together with their reviews, comments, review
+// thread states and files, so a page of PRs costs a single request.
+// Commits, CI checks and timeline events are still fetched with REST, as
+// are files when their patches are wanted: GraphQL exposes neither patches
+// nor the REST IDs of timeline events, and the rest would make each page
+// much larger.
+type graphqlFetcher struct {
+	client *Client
+
+	mu sync.Mutex
+	// listed maps PRs whose reviews and comments were saved from the
+	// listing to whether their files were saved as well. Other PRs, such
+	// as those with more reviews or comments than a page holds, get all
+	// their details from REST.
+	listed map[int]bool
+}
+
+func newGraphQLFetcher(c *Client) *graphqlFetcher {
+	return &graphqlFetcher{client: c, listed: make(map[int]bool)}
+}
+
+func (f *graphqlFetcher) list(ctx context.Context, run *syncRun, pool *workerPool) (listStop, error) {
+	c := f.client
+	pageSize := min(max(c.config.Fetch.BatchSize, 1), graphqlPageSize)
+
+	for {
+		variables := map[string]any{
+			"owner": c.owner,
+			"name":  c.repo,
+			"first": pageSize,
+			"after": nil,
+		}
+		if cursor := run.currentCursor(); cursor != "" {
+			variables["after"] = cursor
+		}
+
+		var data pullRequestsData
+		if err := c.graphql(ctx, pullRequestsQuery, variables, &data); err != nil {
+			return listExhausted, err
+		}
+		conn := data.Repository.PullRequests
+
+		for i := range conn.Nodes {
+			node := &conn.Nodes[i]
+			pr := node.pullRequest()
+			if stop, ok := run.admit(pr.UpdatedAt); !ok {
+				return stop, nil
+			}
+
+			run.enqueue(pr.Number)
+			changed, err := c.savePullRequest(pr, run.refetch)
+			if err != nil {
+				return listExhausted, err
+			}
+
+			if !changed {
+				run.complete(pr.Number)
+				continue
+			}
+			if err := f.saveDetails(node); err != nil {
+				return listExhausted, err
+			}
+			if err := pool.Submit(pr.Number); err != nil {
+				return listExhausted, err
+			}
+		}
+
+		if !conn.PageInfo.HasNextPage {
+			return listExhausted, nil
+		}
+
+		run.nextCursor(conn.PageInfo.EndCursor)
+		if err := c.cache.SaveFetchCheckpoint(run.checkpoint(c.GetRepository())); err != nil {
+			return listExhausted, fmt.Errorf("saving fetch checkpoint: %w", err)
+		}
+	}
+}
+
+// saveDetails caches the reviews, comments and review threads listed with
+// node, unless some of them did not fit in the listing. Files are cached
+// too when they all fit and no patches are wanted.
+func (f *graphqlFetcher) saveDetails(node *graphqlPullRequest) error {
+	if node.truncated() {
+		return nil
+	}
+
+	c := f.client
+	for _, review := range node.reviews() {
+		if err := c.cache.SaveReview(c.GetRepository(), review); err != nil {
+			return fmt.Errorf("saving review %d: %w", review.ID, err)
+		}
+		c.stats.reviews.Add(1)
+	}
+	for _, comment := range node.comments() {
+		if err := c.cache.SaveComment(c.GetRepository(), comment); err != nil {
+			return fmt.Errorf("saving comment %d: %w", comment.ID, err)
+		}
+		c.stats.comments.Add(1)
+	}
+	for _, thread := range node.threads() {
+		if err := c.cache.SaveReviewThread(c.GetRepository(), thread); err != nil {
+			return fmt.Errorf("saving review thread %d: %w", thread.RootID, err)
+		}
+		c.stats.threads.Add(1)
+	}
+
+	files := !c.patches && !node.Files.PageInfo.HasNextPage
+	if files {
+		for _, file := range node.files() {
+			if err := c.cache.SaveFile(c.GetRepository(), file); err != nil {
+				return fmt.Errorf("saving file %s: %w", file.Filename, err)
+			}
+			c.stats.files.Add(1)
+		}
+		// An export with diffs fetches the patches first
+		if err := c.cache.SetPullMissingPatches(c.GetRepository(), node.Number, true); err != nil {
+			return fmt.Errorf("saving PR %d: %w", node.Number, err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listed[node.Number] = files
+	return nil
+}
+
+func (f *graphqlFetcher) details(ctx context.Context, number int) error {
+	f.mu.Lock()
+	files, listed := f.listed[number]
+	delete(f.listed, number)
+	f.mu.Unlock()
+
+	if !listed {
+		return f.client.fetchPRDetails(ctx, number)
+	}
+	if !files {
+		if err := f.client.FetchFiles(ctx, number); err != nil {
+			return fmt.Errorf("fetching files: %w", err)
+		}
+	}
+	if err := f.client.FetchCommits(ctx, number); err != nil {
+		return fmt.Errorf("fetching commits: %w", err)
+	}
+	if err := f.client.FetchChecks(ctx, number); err != nil {
+		return fmt.Errorf("fetching CI checks: %w", err)
+	}
+	if err := f.client.FetchTimeline(ctx, number); err != nil {
+		return fmt.Errorf("fetching timeline: %w", err)
+	}
+	return nil
+}
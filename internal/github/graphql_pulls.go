@@ -0,0 +1,465 @@
+package github
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+const pullRequestsQuery = `query($owner: String!, $name: String!, $first: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequests(first: $first, after: $after, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        fullDatabaseId
+        number
+        title
+        body
+        state
+        isDraft
+        createdAt
+        updatedAt
+        closedAt
+        mergedAt
+        mergedBy { __typename login }
+        mergeCommit { oid }
+        baseRefName
+        baseRefOid
+        headRefName
+        headRefOid
+        milestone { title }
+        author { __typename login }
+        authorAssociation
+        assignees(first: 100) { nodes { __typename login } }
+        reviewRequests(first: 100) { nodes { requestedReviewer { __typename ... on User { login } ... on Team { slug } } } }
+        labels(first: 100) { nodes { name color } }
+        commits { totalCount }
+        files(first: 100) {
+          pageInfo { hasNextPage }
+          nodes { path additions deletions changeType }
+        }
+        reviews(first: 100) {
+          pageInfo { hasNextPage }
+          nodes { fullDatabaseId state body submittedAt author { __typename login } }
+        }
+        comments(first: 100) {
+          pageInfo { hasNextPage }
+          nodes { fullDatabaseId body createdAt updatedAt author { __typename login } ...reactions }
+        }
+        reviewThreads(first: 100) {
+          pageInfo { hasNextPage }
+          nodes {
+            diffSide isResolved isOutdated
+            resolvedBy { __typename login }
+            comments(first: 100) {
+              pageInfo { hasNextPage }
+              nodes {
+                fullDatabaseId body path diffHunk line createdAt updatedAt
+                isMinimized minimizedReason
+                author { __typename login }
+                replyTo { fullDatabaseId }
+                pullRequestReview { fullDatabaseId }
+                ...reactions
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+
+fragment reactions on Reactable {
+  reactionGroups { content reactors { totalCount } }
+}`
+
+type pullRequestsData struct {
+	Repository struct {
+		PullRequests struct {
+			PageInfo graphqlPageInfo      `json:"pageInfo"`
+			Nodes    []graphqlPullRequest `json:"nodes"`
+		} `json:"pullRequests"`
+	} `json:"repository"`
+}
+
+type graphqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphqlID is a BigInt database ID, which GraphQL encodes as a string.
+type graphqlID int64
+
+func (id *graphqlID) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if string(data) == "null" {
+		return nil
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	*id = graphqlID(n)
+	return nil
+}
+
+type graphqlActor struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+	// Slug is set instead of Login for teams requested to review
+	Slug string `json:"slug"`
+}
+
+// user converts the actor the way the REST API reports it.
+func (a *graphqlActor) user() models.User {
+	if a == nil {
+		return models.User{}
+	}
+	login := a.Login
+	// REST logins of apps carry a [bot] suffix
+	if a.Typename == "Bot" {
+		login += "[bot]"
+	}
+	return models.User{Login: login, Type: a.Typename}
+}
+
+type graphqlReactionGroup struct {
+	Content  string `json:"content"`
+	Reactors struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"reactors"`
+}
+
+// restReactions maps GraphQL reaction contents to the REST reaction keys.
+var restReactions = map[string]string{
+	"THUMBS_UP":   "+1",
+	"THUMBS_DOWN": "-1",
+	"LAUGH":       "laugh",
+	"CONFUSED":    "confused",
+	"HEART":       "heart",
+	"HOORAY":      "hooray",
+	"ROCKET":      "rocket",
+	"EYES":        "eyes",
+}
+
+func convertReactionGroups(groups []graphqlReactionGroup) map[string]int {
+	result := make(map[string]int)
+	for _, group := range groups {
+		if key, ok := restReactions[group.Content]; ok && group.Reactors.TotalCount > 0 {
+			result[key] = group.Reactors.TotalCount
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+type graphqlReview struct {
+	FullDatabaseID graphqlID     `json:"fullDatabaseId"`
+	State          string        `json:"state"`
+	Body           string        `json:"body"`
+	SubmittedAt    *time.Time    `json:"submittedAt"`
+	Author         *graphqlActor `json:"author"`
+}
+
+type graphqlComment struct {
+	FullDatabaseID graphqlID     `json:"fullDatabaseId"`
+	Body           string        `json:"body"`
+	Path           string        `json:"path"`
+	DiffHunk       string        `json:"diffHunk"`
+	Line           *int          `json:"line"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+	Author         *graphqlActor `json:"author"`
+	ReplyTo        *struct {
+		FullDatabaseID graphqlID `json:"fullDatabaseId"`
+	} `json:"replyTo"`
+	PullRequestReview *struct {
+		FullDatabaseID graphqlID `json:"fullDatabaseId"`
+	} `json:"pullRequestReview"`
+	ReactionGroups  []graphqlReactionGroup `json:"reactionGroups"`
+	IsMinimized     bool                   `json:"isMinimized"`
+	MinimizedReason string                 `json:"minimizedReason"`
+}
+
+type graphqlCommentConnection struct {
+	PageInfo graphqlPageInfo  `json:"pageInfo"`
+	Nodes    []graphqlComment `json:"nodes"`
+}
+
+type graphqlReviewThread struct {
+	// ID is only requested to page through the thread's comments
+	ID         string                   `json:"id"`
+	DiffSide   string                   `json:"diffSide"`
+	IsResolved bool                     `json:"isResolved"`
+	IsOutdated bool                     `json:"isOutdated"`
+	ResolvedBy *graphqlActor            `json:"resolvedBy"`
+	Comments   graphqlCommentConnection `json:"comments"`
+}
+
+// commentThread converts the state of a review thread of PR number, or
+// returns nil for a thread without comments.
+func (t *graphqlReviewThread) commentThread(number int) *models.CommentThread {
+	if len(t.Comments.Nodes) == 0 {
+		return nil
+	}
+
+	result := &models.CommentThread{
+		// The first comment of a thread is the one the others reply to
+		RootID:     int64(t.Comments.Nodes[0].FullDatabaseID),
+		PullNumber: number,
+		IsResolved: t.IsResolved,
+		IsOutdated: t.IsOutdated,
+	}
+	if t.ResolvedBy != nil {
+		resolvedBy := t.ResolvedBy.user()
+		result.ResolvedBy = &resolvedBy
+	}
+
+	for _, comment := range t.Comments.Nodes {
+		if !comment.IsMinimized {
+			continue
+		}
+		if result.Minimized == nil {
+			result.Minimized = make(map[int64]string)
+		}
+		result.Minimized[int64(comment.FullDatabaseID)] = comment.MinimizedReason
+	}
+
+	return result
+}
+
+type graphqlFile struct {
+	Path       string `json:"path"`
+	Additions  int    `json:"additions"`
+	Deletions  int    `json:"deletions"`
+	ChangeType string `json:"changeType"`
+}
+
+// restFileStatuses maps GraphQL change types to the REST file statuses.
+var restFileStatuses = map[string]string{
+	"ADDED":    "added",
+	"DELETED":  "removed",
+	"MODIFIED": "modified",
+	"RENAMED":  "renamed",
+	"COPIED":   "copied",
+	"CHANGED":  "changed",
+}
+
+type graphqlPullRequest struct {
+	FullDatabaseID graphqlID     `json:"fullDatabaseId"`
+	Number         int           `json:"number"`
+	Title          string        `json:"title"`
+	Body           string        `json:"body"`
+	State          string        `json:"state"`
+	IsDraft        bool          `json:"isDraft"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+	ClosedAt       *time.Time    `json:"closedAt"`
+	MergedAt       *time.Time    `json:"mergedAt"`
+	MergedBy       *graphqlActor `json:"mergedBy"`
+	MergeCommit    *struct {
+		OID string `json:"oid"`
+	} `json:"mergeCommit"`
+	BaseRefName string `json:"baseRefName"`
+	BaseRefOID  string `json:"baseRefOid"`
+	HeadRefName string `json:"headRefName"`
+	HeadRefOID  string `json:"headRefOid"`
+	Milestone   *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	Author            *graphqlActor `json:"author"`
+	AuthorAssociation string        `json:"authorAssociation"`
+	Assignees         struct {
+		Nodes []graphqlActor `json:"nodes"`
+	} `json:"assignees"`
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer *graphqlActor `json:"requestedReviewer"`
+		} `json:"nodes"`
+	} `json:"reviewRequests"`
+	Labels struct {
+		Nodes []models.Label `json:"nodes"`
+	} `json:"labels"`
+	Commits struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"commits"`
+	Files struct {
+		PageInfo graphqlPageInfo `json:"pageInfo"`
+		Nodes    []graphqlFile   `json:"nodes"`
+	} `json:"files"`
+	Reviews struct {
+		PageInfo graphqlPageInfo `json:"pageInfo"`
+		Nodes    []graphqlReview `json:"nodes"`
+	} `json:"reviews"`
+	Comments      graphqlCommentConnection `json:"comments"`
+	ReviewThreads struct {
+		PageInfo graphqlPageInfo       `json:"pageInfo"`
+		Nodes    []graphqlReviewThread `json:"nodes"`
+	} `json:"reviewThreads"`
+}
+
+// pullRequest converts the node into the record the REST listing yields.
+// The listing carries no stats, so they are left empty here as well, except
+// for the number of commits, which FetchCommits records for REST.
+func (p *graphqlPullRequest) pullRequest() *models.PullRequest {
+	result := &models.PullRequest{
+		ID:                int64(p.FullDatabaseID),
+		Number:            p.Number,
+		Title:             p.Title,
+		Body:              p.Body,
+		State:             "open",
+		Draft:             p.IsDraft,
+		Author:            p.Author.user(),
+		AuthorAssociation: p.AuthorAssociation,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+		ClosedAt:          p.ClosedAt,
+		MergedAt:          p.MergedAt,
+		BaseRef:           p.BaseRefName,
+		BaseSHA:           p.BaseRefOID,
+		HeadRef:           p.HeadRefName,
+		HeadSHA:           p.HeadRefOID,
+		Stats:             models.PullRequestStats{Commits: p.Commits.TotalCount},
+	}
+
+	// REST reports merged PRs as closed
+	if p.State != "OPEN" {
+		result.State = "closed"
+	}
+
+	if p.MergedBy != nil {
+		mergedBy := p.MergedBy.user()
+		result.MergedBy = &mergedBy
+	}
+	if p.MergeCommit != nil {
+		result.MergeCommitSHA = p.MergeCommit.OID
+	}
+	if p.Milestone != nil {
+		result.Milestone = p.Milestone.Title
+	}
+
+	for i := range p.Assignees.Nodes {
+		result.Assignees = append(result.Assignees, p.Assignees.Nodes[i].user())
+	}
+
+	// REST lists requested teams separately from requested users
+	for _, request := range p.ReviewRequests.Nodes {
+		switch reviewer := request.RequestedReviewer; {
+		case reviewer == nil:
+		case reviewer.Typename == "User":
+			result.RequestedReviewers = append(result.RequestedReviewers, reviewer.user())
+		case reviewer.Typename == "Team":
+			result.RequestedTeams = append(result.RequestedTeams, reviewer.Slug)
+		}
+	}
+
+	result.Labels = append(result.Labels, p.Labels.Nodes...)
+
+	return result
+}
+
+// truncated reports whether some reviews or comments did not fit in the listing.
+func (p *graphqlPullRequest) truncated() bool {
+	if p.Reviews.PageInfo.HasNextPage || p.Comments.PageInfo.HasNextPage || p.ReviewThreads.PageInfo.HasNextPage {
+		return true
+	}
+	for _, thread := range p.ReviewThreads.Nodes {
+		if thread.Comments.PageInfo.HasNextPage {
+			return true
+		}
+	}
+	return false
+}
+
+// files returns the changed files without their patches, which GraphQL
+// does not expose.
+func (p *graphqlPullRequest) files() []*models.File {
+	files := make([]*models.File, 0, len(p.Files.Nodes))
+	for _, file := range p.Files.Nodes {
+		files = append(files, &models.File{
+			PullNumber: p.Number,
+			Filename:   file.Path,
+			Status:     restFileStatuses[file.ChangeType],
+			Additions:  file.Additions,
+			Deletions:  file.Deletions,
+		})
+	}
+	return files
+}
+
+func (p *graphqlPullRequest) reviews() []*models.Review {
+	reviews := make([]*models.Review, 0, len(p.Reviews.Nodes))
+	for _, review := range p.Reviews.Nodes {
+		result := &models.Review{
+			ID:         int64(review.FullDatabaseID),
+			PullNumber: p.Number,
+			Reviewer:   review.Author.user(),
+			State:      review.State,
+			Body:       review.Body,
+		}
+		if review.SubmittedAt != nil {
+			result.SubmittedAt = *review.SubmittedAt
+		}
+		reviews = append(reviews, result)
+	}
+	return reviews
+}
+
+// comments returns the PR's issue comments followed by its review comments.
+func (p *graphqlPullRequest) comments() []*models.Comment {
+	var comments []*models.Comment
+	for i := range p.Comments.Nodes {
+		comments = append(comments, p.convertComment(&p.Comments.Nodes[i]))
+	}
+
+	for _, thread := range p.ReviewThreads.Nodes {
+		for i := range thread.Comments.Nodes {
+			comment := p.convertComment(&thread.Comments.Nodes[i])
+			comment.Side = thread.DiffSide
+			comments = append(comments, comment)
+		}
+	}
+	return comments
+}
+
+func (p *graphqlPullRequest) threads() []*models.CommentThread {
+	var threads []*models.CommentThread
+	for i := range p.ReviewThreads.Nodes {
+		if thread := p.ReviewThreads.Nodes[i].commentThread(p.Number); thread != nil {
+			threads = append(threads, thread)
+		}
+	}
+	return threads
+}
+
+func (p *graphqlPullRequest) convertComment(comment *graphqlComment) *models.Comment {
+	result := &models.Comment{
+		ID:         int64(comment.FullDatabaseID),
+		PullNumber: p.Number,
+		Author:     comment.Author.user(),
+		Body:       comment.Body,
+		Path:       comment.Path,
+		DiffHunk:   comment.DiffHunk,
+		Line:       comment.Line,
+		CreatedAt:  comment.CreatedAt,
+		UpdatedAt:  comment.UpdatedAt,
+		Reactions:  convertReactionGroups(comment.ReactionGroups),
+	}
+
+	if comment.ReplyTo != nil {
+		id := int64(comment.ReplyTo.FullDatabaseID)
+		result.InReplyToID = &id
+	}
+
+	if comment.PullRequestReview != nil {
+		id := int64(comment.PullRequestReview.FullDatabaseID)
+		result.ReviewID = &id
+	}
+
+	return result
+}
@@ -0,0 +1,326 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// restPullFixtures and graphqlPullFixture describe the same PR as the REST
+// and GraphQL APIs return it.
+var restPullFixtures = map[string]string{
+	"/repos/owner/repo/pulls": `[{
+		"id": 3000000001, "number": 1, "title": "Add feature", "body": "Closes #7", "state": "closed", "draft": false,
+		"user": {"login": "alice", "type": "User"}, "author_association": "MEMBER",
+		"assignees": [{"login": "bob", "type": "User"}],
+		"requested_reviewers": [{"login": "carol", "type": "User"}],
+		"requested_teams": [{"slug": "core"}],
+		"labels": [{"name": "bug", "color": "d73a4a"}],
+		"milestone": {"title": "v1.2"},
+		"created_at": "2024-01-01T10:00:00Z", "updated_at": "2024-01-03T10:00:00Z",
+		"closed_at": "2024-01-03T09:00:00Z", "merged_at": "2024-01-03T09:00:00Z",
+		"merge_commit_sha": "ccc333",
+		"base": {"ref": "main", "sha": "aaa111"}, "head": {"ref": "feature", "sha": "bbb222"}
+	}]`,
+	"/repos/owner/repo/pulls/1/reviews": `[{
+		"id": 3000000002, "state": "APPROVED", "body": "LGTM",
+		"submitted_at": "2024-01-02T10:00:00Z",
+		"user": {"login": "renovate[bot]", "type": "Bot"}
+	}]`,
+	"/repos/owner/repo/issues/1/comments": `[{
+		"id": 3000000003, "body": "Thanks!",
+		"created_at": "2024-01-02T11:00:00Z", "updated_at": "2024-01-02T11:00:00Z",
+		"user": {"login": "bob", "type": "User"},
+		"reactions": {"+1": 2, "heart": 1, "laugh": 0}
+	}]`,
+	"/repos/owner/repo/pulls/1/comments": `[{
+		"id": 3000000004, "body": "Nit", "path": "main.go", "diff_hunk": "@@ -1 +1 @@", "line": 12,
+		"side": "RIGHT", "pull_request_review_id": 3000000002,
+		"created_at": "2024-01-02T12:00:00Z", "updated_at": "2024-01-02T12:00:00Z",
+		"user": {"login": "carol", "type": "User"}
+	}, {
+		"id": 3000000005, "body": "Fixed", "path": "main.go", "diff_hunk": "@@ -1 +1 @@", "line": 12,
+		"side": "RIGHT", "in_reply_to_id": 3000000004,
+		"created_at": "2024-01-02T13:00:00Z", "updated_at": "2024-01-02T13:00:00Z",
+		"user": {"login": "alice", "type": "User"}
+	}]`,
+	"/repos/owner/repo/pulls/1/commits": `[{
+		"sha": "bbb222", "author": {"login": "alice", "type": "User"}, "committer": {"login": "web-flow", "type": "User"},
+		"commit": {
+			"message": "Add feature\n\nCo-authored-by: Bob <bob@example.com>",
+			"author": {"name": "Alice", "email": "alice@example.com", "date": "2024-01-01T09:00:00Z"},
+			"committer": {"name": "GitHub", "email": "noreply@github.com", "date": "2024-01-01T09:30:00Z"},
+			"verification": {"verified": true, "reason": "valid"}
+		}
+	}]`,
+	"/repos/owner/repo/issues/1/timeline": `[{
+		"id": 3000000006, "event": "review_requested", "created_at": "2024-01-01T11:00:00Z",
+		"actor": {"login": "alice", "type": "User"}, "requested_reviewer": {"login": "carol", "type": "User"}
+	}, {
+		"event": "commented", "created_at": "2024-01-02T11:00:00Z", "user": {"login": "bob", "type": "User"}
+	}, {
+		"id": 3000000007, "event": "labeled", "created_at": "2024-01-01T12:00:00Z",
+		"actor": {"login": "alice", "type": "User"}, "label": {"name": "bug"}
+	}, {
+		"id": 3000000008, "event": "merged", "created_at": "2024-01-03T09:00:00Z",
+		"actor": {"login": "dave", "type": "User"}, "commit_id": "ccc333"
+	}]`,
+	"/repos/owner/repo/commits/bbb222/check-runs": `{"total_count": 2, "check_runs": [{
+		"id": 4000000001, "head_sha": "bbb222", "name": "test", "status": "completed", "conclusion": "failure",
+		"started_at": "2024-01-02T09:00:00Z", "completed_at": "2024-01-02T09:05:00Z", "app": {"slug": "github-actions"}
+	}, {
+		"id": 4000000002, "head_sha": "bbb222", "name": "test", "status": "completed", "conclusion": "success",
+		"started_at": "2024-01-02T09:10:00Z", "completed_at": "2024-01-02T09:15:00Z", "app": {"slug": "github-actions"}
+	}]}`,
+	"/repos/owner/repo/commits/bbb222/statuses": `[{
+		"id": 5000000001, "context": "ci/jenkins", "state": "pending",
+		"created_at": "2024-01-02T09:01:00Z", "updated_at": "2024-01-02T09:01:00Z", "creator": {"login": "jenkins"}
+	}]`,
+	"/repos/owner/repo/pulls/1/files": `[{"filename": "main.go", "status": "modified", "additions": 1, "deletions": 1, "patch": "@@ -1 +1 @@"}]`,
+}
+
+const graphqlPullFixture = `{"data": {"repository": {"pullRequests": {
+	"pageInfo": {"hasNextPage": false, "endCursor": "c1"},
+	"nodes": [{
+		"fullDatabaseId": "3000000001", "number": 1, "title": "Add feature", "body": "Closes #7",
+		"state": "MERGED", "isDraft": false,
+		"createdAt": "2024-01-01T10:00:00Z", "updatedAt": "2024-01-03T10:00:00Z",
+		"closedAt": "2024-01-03T09:00:00Z", "mergedAt": "2024-01-03T09:00:00Z",
+		"mergedBy": {"__typename": "User", "login": "dave"}, "mergeCommit": {"oid": "ccc333"},
+		"baseRefName": "main", "baseRefOid": "aaa111", "headRefName": "feature", "headRefOid": "bbb222",
+		"milestone": {"title": "v1.2"},
+		"author": {"__typename": "User", "login": "alice"}, "authorAssociation": "MEMBER",
+		"assignees": {"nodes": [{"__typename": "User", "login": "bob"}]},
+		"reviewRequests": {"nodes": [
+			{"requestedReviewer": {"__typename": "User", "login": "carol"}},
+			{"requestedReviewer": {"__typename": "Team", "slug": "core"}}
+		]},
+		"labels": {"nodes": [{"name": "bug", "color": "d73a4a"}]},
+		"commits": {"totalCount": 1},
+		"files": {"pageInfo": {"hasNextPage": false}, "nodes": [
+			{"path": "main.go", "additions": 1, "deletions": 1, "changeType": "MODIFIED"}
+		]},
+		"reviews": {"pageInfo": {"hasNextPage": false}, "nodes": [{
+			"fullDatabaseId": "3000000002", "state": "APPROVED", "body": "LGTM",
+			"submittedAt": "2024-01-02T10:00:00Z", "author": {"__typename": "Bot", "login": "renovate"}
+		}]},
+		"comments": {"pageInfo": {"hasNextPage": false}, "nodes": [{
+			"fullDatabaseId": "3000000003", "body": "Thanks!",
+			"createdAt": "2024-01-02T11:00:00Z", "updatedAt": "2024-01-02T11:00:00Z",
+			"author": {"__typename": "User", "login": "bob"},
+			"reactionGroups": [
+				{"content": "THUMBS_UP", "reactors": {"totalCount": 2}},
+				{"content": "HEART", "reactors": {"totalCount": 1}},
+				{"content": "LAUGH", "reactors": {"totalCount": 0}}
+			]
+		}]},
+		"reviewThreads": {"pageInfo": {"hasNextPage": false}, "nodes": [{
+			"diffSide": "RIGHT", "isResolved": true, "isOutdated": false,
+			"resolvedBy": {"__typename": "User", "login": "carol"},
+			"comments": {"pageInfo": {"hasNextPage": false}, "nodes": [{
+				"fullDatabaseId": "3000000004", "body": "Nit", "path": "main.go", "diffHunk": "@@ -1 +1 @@", "line": 12,
+				"createdAt": "2024-01-02T12:00:00Z", "updatedAt": "2024-01-02T12:00:00Z",
+				"isMinimized": true, "minimizedReason": "resolved",
+				"author": {"__typename": "User", "login": "carol"},
+				"replyTo": null, "pullRequestReview": {"fullDatabaseId": "3000000002"}, "reactionGroups": []
+			}, {
+				"fullDatabaseId": "3000000005", "body": "Fixed", "path": "main.go", "diffHunk": "@@ -1 +1 @@", "line": 12,
+				"createdAt": "2024-01-02T13:00:00Z", "updatedAt": "2024-01-02T13:00:00Z",
+				"author": {"__typename": "User", "login": "alice"},
+				"replyTo": {"fullDatabaseId": "3000000004"}, "pullRequestReview": null, "reactionGroups": []
+			}]}
+		}]}
+	}]
+}}}}`
+
+// graphqlThreadsFixture holds the review threads of the PR as the REST
+// fetcher requests them.
+const graphqlThreadsFixture = `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+	"pageInfo": {"hasNextPage": false, "endCursor": "t1"},
+	"nodes": [{
+		"isResolved": true, "isOutdated": false,
+		"resolvedBy": {"__typename": "User", "login": "carol"},
+		"comments": {"nodes": [
+			{"fullDatabaseId": "3000000004", "isMinimized": true, "minimizedReason": "resolved"},
+			{"fullDatabaseId": "3000000005", "isMinimized": false, "minimizedReason": null}
+		]}
+	}]
+}}}}}`
+
+func serveFixtures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/graphql" {
+		query, _ := io.ReadAll(r.Body)
+		if bytes.Contains(query, []byte("pullRequest(number:")) {
+			_, _ = w.Write([]byte(graphqlThreadsFixture))
+			return
+		}
+		_, _ = w.Write([]byte(graphqlPullFixture))
+		return
+	}
+	body, ok := restPullFixtures[r.URL.Path]
+	if !ok {
+		body = "[]"
+	}
+	_, _ = w.Write([]byte(body))
+}
+
+func TestGraphQLFetcherMatchesREST(t *testing.T) {
+	ctx := context.Background()
+
+	restClient, restStore := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Who merged the PR comes from the timeline, not from a request per PR
+		if r.URL.Path == "/repos/owner/repo/pulls/1" {
+			t.Errorf("REST fetch requested %s", r.URL.Path)
+		}
+		serveFixtures(w, r)
+	}))
+	if _, err := restClient.FetchPullRequests(ctx, FetchOptions{Limit: 100}); err != nil {
+		t.Fatalf("REST FetchPullRequests() error = %v", err)
+	}
+
+	graphqlRequests := 0
+	graphqlClient, graphqlStore := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graphql":
+			graphqlRequests++
+		case "/repos/owner/repo/pulls/1/commits", "/repos/owner/repo/issues/1/timeline",
+			"/repos/owner/repo/commits/bbb222/check-runs", "/repos/owner/repo/commits/bbb222/statuses":
+		default:
+			t.Errorf("GraphQL fetch requested %s", r.URL.Path)
+		}
+		serveFixtures(w, r)
+	}))
+	graphqlClient.fetcher = newGraphQLFetcher(graphqlClient)
+	result, err := graphqlClient.FetchPullRequests(ctx, FetchOptions{Limit: 100})
+	if err != nil {
+		t.Fatalf("GraphQL FetchPullRequests() error = %v", err)
+	}
+	if graphqlRequests != 1 || result.New != 1 || result.Reviews != 1 || result.Comments != 3 || result.Threads != 1 || result.Files != 1 || result.Commits != 1 || result.Checks != 3 || result.TimelineEvents != 3 {
+		t.Errorf("GraphQL fetch = %+v with %d GraphQL requests, want 1 PR with all details from one request", result, graphqlRequests)
+	}
+
+	restPR, _ := restStore.GetPullRequest("owner/repo", 1)
+	graphqlPR, err := graphqlStore.GetPullRequest("owner/repo", 1)
+	if err != nil || !reflect.DeepEqual(graphqlPR, restPR) {
+		t.Errorf("GraphQL PR = %+v, %v\nwant %+v", graphqlPR, err, restPR)
+	}
+	if restPR.MergedBy == nil || restPR.MergedBy.Login != "dave" || restPR.HeadSHA != "bbb222" ||
+		restPR.Milestone != "v1.2" || !slices.Equal(restPR.RequestedTeams, []string{"core"}) || restPR.Stats.Commits != 1 {
+		t.Errorf("REST PR = %+v, want merge, branch, milestone, team and commit details", restPR)
+	}
+	restReviews, _ := restStore.GetReviews("owner/repo", 1)
+	graphqlReviews, err := graphqlStore.GetReviews("owner/repo", 1)
+	if err != nil || !reflect.DeepEqual(graphqlReviews, restReviews) {
+		t.Errorf("GraphQL reviews = %+v, %v\nwant %+v", graphqlReviews, err, restReviews)
+	}
+	restComments, _ := restStore.GetComments("owner/repo", 1)
+	graphqlComments, err := graphqlStore.GetComments("owner/repo", 1)
+	if err != nil || !reflect.DeepEqual(graphqlComments, restComments) {
+		t.Errorf("GraphQL comments = %+v, %v\nwant %+v", graphqlComments, err, restComments)
+	}
+
+	restThreads, _ := restStore.GetThreadsByPull("owner/repo", []int{1})
+	threads, err := graphqlStore.GetThreadsByPull("owner/repo", []int{1})
+	if err != nil || !reflect.DeepEqual(threads, restThreads) {
+		t.Errorf("GraphQL threads = %+v, %v\nwant %+v", threads, err, restThreads)
+	}
+	if th := threads[1]; len(th) != 1 || th[0].RootID != 3000000004 || !th[0].IsResolved ||
+		th[0].ResolvedBy == nil || th[0].ResolvedBy.Login != "carol" || th[0].Minimized[3000000004] != "resolved" {
+		t.Errorf("threads = %+v, want the thread resolved by carol with its first comment hidden", th)
+	}
+
+	timeline, err := graphqlStore.GetTimelineByPull("owner/repo", []int{1})
+	if err != nil {
+		t.Fatalf("GetTimelineByPull() error = %v", err)
+	}
+	if events := timeline[1]; len(events) != 3 || events[0].Event != "review_requested" ||
+		events[0].RequestedReviewer == nil || events[0].RequestedReviewer.Login != "carol" || events[1].Label != "bug" ||
+		events[2].Event != "merged" {
+		t.Errorf("timeline = %+v, want the review request, the label and the merge, without the comment", events)
+	}
+
+	commits, err := graphqlStore.GetCommitsByPull("owner/repo", []int{1})
+	if err != nil {
+		t.Fatalf("GetCommitsByPull() error = %v", err)
+	}
+	if c := commits[1]; len(c) != 1 || c[0].Author.Login != "alice" || !c[0].Verified ||
+		len(c[0].CoAuthors) != 1 || c[0].CoAuthors[0].Email != "bob@example.com" {
+		t.Errorf("commits = %+v, want alice's verified commit co-authored by bob", c)
+	}
+
+	restChecks, _ := restStore.GetChecksByPull("owner/repo", []int{1})
+	checks, err := graphqlStore.GetChecksByPull("owner/repo", []int{1})
+	if err != nil || !reflect.DeepEqual(checks, restChecks) {
+		t.Errorf("GraphQL checks = %+v, %v\nwant %+v", checks, err, restChecks)
+	}
+	if c := checks[1]; len(c) != 3 || c[0].App != "github-actions" || c[1].Kind != "status" ||
+		c[1].Status != "in_progress" || c[1].CompletedAt != nil || c[2].Conclusion != "success" {
+		t.Errorf("checks = %+v, want both test runs with the pending status between them", c)
+	}
+
+	// GraphQL has no patches, so the listed files match REST without them
+	restFiles, _ := restStore.GetFiles("owner/repo", 1)
+	for _, file := range restFiles {
+		file.Patch = ""
+	}
+	graphqlFiles, err := graphqlStore.GetFiles("owner/repo", 1)
+	if err != nil || !reflect.DeepEqual(graphqlFiles, restFiles) {
+		t.Errorf("GraphQL files = %+v, %v\nwant %+v", graphqlFiles, err, restFiles)
+	}
+}
+
+func TestGraphQLFetcherFetchesPatchesWithREST(t *testing.T) {
+	fileRequests := 0
+	client, store := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/owner/repo/pulls/1/files" {
+			fileRequests++
+		}
+		serveFixtures(w, r)
+	}))
+	client.fetcher = newGraphQLFetcher(client)
+	ctx := context.Background()
+
+	if _, err := client.FetchPullRequests(ctx, FetchOptions{Limit: 100}); err != nil {
+		t.Fatalf("first FetchPullRequests() error = %v", err)
+	}
+	missing, err := store.GetPullsMissingPatches("owner/repo")
+	if err != nil || fileRequests != 0 || !slices.Equal(missing, []int{1}) {
+		t.Errorf("PRs missing patches = %v, %v after %d file requests, want PR 1 listed with GraphQL only", missing, err, fileRequests)
+	}
+
+	// The incremental sync lists nothing new, but the patches are still fetched
+	result, err := client.FetchPullRequests(ctx, FetchOptions{Limit: 100, Patches: true})
+	if err != nil {
+		t.Fatalf("second FetchPullRequests() error = %v", err)
+	}
+	if fileRequests != 1 || result.Files != 1 {
+		t.Errorf("fetch = %+v with %d file requests, want the file once from REST", result, fileRequests)
+	}
+
+	files, err := store.GetFiles("owner/repo", 1)
+	if err != nil || len(files) != 1 || files[0].Status != "modified" || files[0].Patch != "@@ -1 +1 @@" {
+		t.Errorf("files = %+v, %v, want main.go with its patch", files, err)
+	}
+	if missing, err := store.GetPullsMissingPatches("owner/repo"); err != nil || len(missing) != 0 {
+		t.Errorf("PRs missing patches = %v, %v, want none", missing, err)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com":             "https://api.github.com/graphql",
+		"https://api.github.com/":            "https://api.github.com/graphql",
+		"https://github.example.com/api/v3":  "https://github.example.com/api/graphql",
+		"https://github.example.com/api/v3/": "https://github.example.com/api/graphql",
+	}
+	for apiURL, want := range tests {
+		if got := graphqlURL(apiURL); got != want {
+			t.Errorf("graphqlURL(%q) = %q, want %q", apiURL, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool runs a task for each submitted PR number on a bounded number of
+// goroutines. The first failing task cancels the pool's context so that
+// in-flight and queued work stops early.
+type workerPool struct {
+	jobs   chan int
+	wg     sync.WaitGroup
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	errOnce sync.Once
+	err     error
+}
+
+func newWorkerPool(ctx context.Context, size int, task func(ctx context.Context, number int) error) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+
+	poolCtx, cancel := context.WithCancel(ctx)
+	p := &workerPool{
+		jobs:   make(chan int),
+		parent: ctx,
+		ctx:    poolCtx,
+		cancel: cancel,
+	}
+
+	for i := 0; i < size; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for number := range p.jobs {
+				if p.ctx.Err() != nil {
+					continue
+				}
+				if err := task(p.ctx, number); err != nil {
+					p.fail(err)
+				}
+			}
+		}()
+	}
+
+	return p
+}
+
+// Submit queues number for processing, blocking until a worker is free.
+// It returns an error once the pool has been cancelled.
+func (p *workerPool) Submit(number int) error {
+	select {
+	case p.jobs <- number:
+		return nil
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// Wait stops accepting work, waits for running tasks and returns the first error.
+func (p *workerPool) Wait() error {
+	close(p.jobs)
+	p.wg.Wait()
+	p.cancel()
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.parent.Err()
+}
+
+func (p *workerPool) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkerPoolStopsAfterFirstError(t *testing.T) {
+	errBoom := errors.New("boom")
+	var processed atomic.Int32
+
+	pool := newWorkerPool(context.Background(), 2, func(ctx context.Context, number int) error {
+		processed.Add(1)
+		if number == 1 {
+			return errBoom
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	for number := 1; number <= 100; number++ {
+		if err := pool.Submit(number); err != nil {
+			break
+		}
+	}
+
+	if err := pool.Wait(); !errors.Is(err, errBoom) {
+		t.Errorf("Wait() error = %v, want %v", err, errBoom)
+	}
+	if got := processed.Load(); got >= 100 {
+		t.Errorf("processed %d tasks, want remaining work to be cancelled", got)
+	}
+}
+
+func TestWorkerPoolSucceeds(t *testing.T) {
+	var processed atomic.Int32
+	pool := newWorkerPool(context.Background(), 4, func(ctx context.Context, number int) error {
+		processed.Add(1)
+		return nil
+	})
+
+	for number := 1; number <= 10; number++ {
+		if err := pool.Submit(number); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+
+	if err := pool.Wait(); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+	if got := processed.Load(); got != 10 {
+		t.Errorf("processed %d tasks, want 10", got)
+	}
+}
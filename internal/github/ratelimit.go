@@ -0,0 +1,361 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// Pause used for secondary limits that come without a Retry-After header
+	secondaryLimitPause = time.Minute
+	// Extra time after the advertised reset to absorb clock skew
+	resetSlack = time.Second
+	// Upper bound on back-to-back rate limit responses for a single request
+	maxRateLimitRetries = 10
+
+	primaryLimitReason = "rate limit exceeded"
+)
+
+// PauseFunc is called when requests are paused until the given time.
+type PauseFunc func(until time.Time, reason string)
+
+type rateBudget struct {
+	remaining int
+	reset     time.Time
+	known     bool
+}
+
+// budgetKey identifies the budget of one credential for one resource.
+type budgetKey struct {
+	credential int
+	resource   string
+}
+
+// rateLimiter tracks the API budget reported by GitHub for each credential
+// and picks the credential for each request. It keeps using one credential
+// until its remaining budget drops below the configured buffer, then
+// rotates to the credential with the most budget left, and holds requests
+// back once every credential is below the buffer.
+type rateLimiter struct {
+	mu          sync.Mutex
+	buffer      int
+	credentials int
+	current     int
+	budgets     map[budgetKey]*rateBudget
+	requests    []int
+	pausedUntil time.Time
+	onPause     PauseFunc
+}
+
+func newRateLimiter(buffer int) *rateLimiter {
+	return newPooledRateLimiter(buffer, 1)
+}
+
+func newPooledRateLimiter(buffer, credentials int) *rateLimiter {
+	return &rateLimiter{
+		buffer:      buffer,
+		credentials: credentials,
+		budgets:     make(map[budgetKey]*rateBudget),
+		requests:    make([]int, credentials),
+	}
+}
+
+func (l *rateLimiter) setPauseFunc(fn PauseFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onPause = fn
+}
+
+// wait blocks until the limiter allows a request for resource and returns
+// the credential to send it with.
+func (l *rateLimiter) wait(ctx context.Context, resource string) (int, error) {
+	for {
+		l.mu.Lock()
+		credential, ok := l.pickLocked(resource)
+		if !ok {
+			l.pauseLocked(l.earliestResetLocked(resource).Add(resetSlack), "rate limit buffer reached")
+		}
+		until := l.pausedUntil
+		if ok && !until.After(time.Now()) {
+			l.current = credential
+			l.requests[credential]++
+			l.mu.Unlock()
+			return credential, nil
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// pickLocked returns the current credential while it is above the buffer,
+// otherwise the credential with the most budget left that is.
+func (l *rateLimiter) pickLocked(resource string) (int, bool) {
+	if l.availableLocked(l.current, resource) >= 0 {
+		return l.current, true
+	}
+
+	best, bestRemaining := 0, -1
+	for credential := 0; credential < l.credentials; credential++ {
+		if remaining := l.availableLocked(credential, resource); remaining > bestRemaining {
+			best, bestRemaining = credential, remaining
+		}
+	}
+	return best, bestRemaining >= 0
+}
+
+// availableLocked returns the budget a credential has left for resource,
+// or -1 when it is below the buffer. Unknown budgets count as full.
+func (l *rateLimiter) availableLocked(credential int, resource string) int {
+	budget, ok := l.budgets[budgetKey{credential, resource}]
+	if !ok || !budget.known {
+		return math.MaxInt
+	}
+	if !budget.reset.After(time.Now()) {
+		// The window has reset; let the next request refresh the budget
+		budget.known = false
+		return math.MaxInt
+	}
+	// Never send a request once the budget is exhausted, even without a buffer
+	if budget.remaining < max(l.buffer, 1) {
+		return -1
+	}
+	return budget.remaining
+}
+
+func (l *rateLimiter) earliestResetLocked(resource string) time.Time {
+	var earliest time.Time
+	for credential := 0; credential < l.credentials; credential++ {
+		budget, ok := l.budgets[budgetKey{credential, resource}]
+		if ok && (earliest.IsZero() || budget.reset.Before(earliest)) {
+			earliest = budget.reset
+		}
+	}
+	return earliest
+}
+
+// update records the budget advertised by a response to credential.
+func (l *rateLimiter) update(credential int, resource string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	if name := header.Get("X-RateLimit-Resource"); name != "" {
+		resource = name
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.budgets[budgetKey{credential, resource}] = &rateBudget{
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+		known:     true,
+	}
+}
+
+// TokenUsage reports how much one credential was used during a fetch.
+type TokenUsage struct {
+	// Name identifies the token without revealing it
+	Name     string
+	Requests int
+	// Remaining is the core API budget last reported, or -1 if unknown
+	Remaining int
+}
+
+// usage returns the requests sent with each credential since the last
+// reset of the counters.
+func (l *rateLimiter) usage(names []string) []TokenUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := make([]TokenUsage, l.credentials)
+	for credential := range usage {
+		usage[credential] = TokenUsage{Requests: l.requests[credential], Remaining: -1}
+		if credential < len(names) {
+			usage[credential].Name = names[credential]
+		}
+		if budget, ok := l.budgets[budgetKey{credential, "core"}]; ok {
+			usage[credential].Remaining = budget.remaining
+		}
+	}
+	return usage
+}
+
+func (l *rateLimiter) resetUsage() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.requests)
+}
+
+func (l *rateLimiter) pause(until time.Time, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pauseLocked(until, reason)
+}
+
+func (l *rateLimiter) pauseLocked(until time.Time, reason string) {
+	if !until.After(l.pausedUntil) {
+		return
+	}
+	// Only announce pauses that start a new wait, not extensions of one
+	announce := !l.pausedUntil.After(time.Now())
+	l.pausedUntil = until
+	if announce && l.onPause != nil {
+		l.onPause(until, reason)
+	}
+}
+
+// rateLimitTransport pauses and retries requests that run into GitHub's
+// primary or secondary rate limits instead of surfacing them as errors.
+// When tokens is set, each request is authorized with the credential the
+// limiter picks, and its context carries the credential's ID from
+// credentials for etagTransport.
+type rateLimitTransport struct {
+	base        http.RoundTripper
+	limiter     *rateLimiter
+	tokens      []oauth2.TokenSource
+	credentials []string
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := rateLimitResource(req)
+
+	for attempt := 0; ; attempt++ {
+		credential, err := t.limiter.wait(req.Context(), resource)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.send(req, credential)
+		if err != nil {
+			return nil, err
+		}
+		t.limiter.update(credential, resource, resp.Header)
+
+		until, reason, limited := rateLimitedUntil(resp)
+		if !limited {
+			// go-github refuses further calls while its last seen budget is
+			// exhausted. With a single credential, hold this response until
+			// the window resets; with several, hide the exhausted budget
+			// since the limiter rotates to another credential.
+			if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+				if t.limiter.credentials > 1 {
+					resp.Header.Del("X-RateLimit-Remaining")
+					resp.Header.Del("X-RateLimit-Reset")
+				} else if _, err := t.limiter.wait(req.Context(), resource); err != nil {
+					resp.Body.Close()
+					return nil, err
+				}
+			}
+			return resp, nil
+		}
+
+		if attempt >= maxRateLimitRetries || !rewindBody(req) {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		// An exhausted primary limit only stops the credential that hit it,
+		// which the limiter already knows from the response headers
+		if reason != primaryLimitReason {
+			t.limiter.pause(until, reason)
+		}
+	}
+}
+
+// send sends req authorized with credential.
+func (t *rateLimitTransport) send(req *http.Request, credential int) (*http.Response, error) {
+	if t.tokens == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	token, err := t.tokens[credential].Token()
+	if err != nil {
+		return nil, err
+	}
+	ctx := req.Context()
+	if credential < len(t.credentials) {
+		ctx = withCredential(ctx, t.credentials[credential])
+	}
+	authorized := req.Clone(ctx)
+	token.SetAuthHeader(authorized)
+	return t.base.RoundTrip(authorized)
+}
+
+// rateLimitedUntil reports whether resp is a rate limit rejection and when
+// the request may be retried.
+func rateLimitedUntil(resp *http.Response) (time.Time, string, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, "", false
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Now().Add(time.Duration(seconds) * time.Second), "secondary rate limit", true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0).Add(resetSlack), primaryLimitReason, true
+		}
+	}
+
+	// Secondary limits are sometimes reported only in the message body
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		return time.Now().Add(secondaryLimitPause), "secondary rate limit", true
+	}
+
+	return time.Time{}, "", false
+}
+
+// rateLimitResource guesses the rate limit bucket a request is charged to.
+func rateLimitResource(req *http.Request) string {
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	case strings.Contains(req.URL.Path, "/search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
+
+// rewindBody prepares req to be sent again, reporting whether that is possible.
+func rewindBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	req.Body = body
+	return true
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRateLimitTransportRetriesSecondaryLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limiter := newRateLimiter(0)
+	var pauses atomic.Int32
+	limiter.setPauseFunc(func(until time.Time, reason string) { pauses.Add(1) })
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, limiter: limiter}}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+	if got := pauses.Load(); got != 1 {
+		t.Errorf("pause notifications = %d, want 1", got)
+	}
+}
+
+func TestRateLimitTransportWaitsBelowBuffer(t *testing.T) {
+	reset := time.Now().Add(time.Second)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining := 5
+		if calls.Add(1) > 1 {
+			remaining = 5000
+		}
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limiter := newRateLimiter(10)
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, limiter: limiter}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if time.Now().Before(time.Unix(reset.Unix(), 0)) {
+		t.Error("second request was sent before the rate limit window reset")
+	}
+}
+
+func TestRateLimitTransportRotatesTokens(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	remaining := map[string]int{"Bearer token-a": 11, "Bearer token-b": 1000}
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		seen = append(seen, auth)
+		remaining[auth]--
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining[auth]))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limiter := newPooledRateLimiter(10, 2)
+	tokens := []oauth2.TokenSource{
+		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token-a"}),
+		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token-b"}),
+	}
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, limiter: limiter, tokens: tokens}}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// token-a drops below the buffer of 10 after its second request
+	want := []string{"Bearer token-a", "Bearer token-a", "Bearer token-b"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("Authorization headers = %v, want %v", seen, want)
+	}
+
+	usage := limiter.usage([]string{"a", "b"})
+	if usage[0].Requests != 2 || usage[1].Requests != 1 || usage[1].Remaining != 999 {
+		t.Errorf("usage = %+v, want 2 requests with a and 1 with b", usage)
+	}
+}
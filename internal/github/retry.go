@@ -0,0 +1,125 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Delay before the first retry, doubled for every further attempt
+	retryBaseDelay = 500 * time.Millisecond
+	// Upper bound on the delay between two attempts
+	retryMaxDelay = 30 * time.Second
+)
+
+// idempotentKey marks a request context whose non-GET requests may still
+// be retried, such as GraphQL queries.
+type idempotentKey struct{}
+
+func withIdempotentRequests(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// retryTransport retries idempotent requests that fail with a server
+// error, a timeout or a dropped connection, backing off exponentially with
+// jitter. Client errors such as 401 and 404 are never retried.
+type retryTransport struct {
+	base http.RoundTripper
+	// maxAttempts bounds the attempts per request, including the first
+	maxAttempts int
+	// maxElapsed bounds the time spent retrying a request; zero means no bound
+	maxElapsed time.Duration
+	baseDelay  time.Duration
+	onRetry    func()
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if !shouldRetry(req.Context(), resp, err) || attempt >= t.maxAttempts || !rewindBody(req) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if t.maxElapsed > 0 && time.Since(start)+delay > t.maxElapsed {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if t.onRetry != nil {
+			t.onRetry()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the attempt after the given one. The
+// exponential delay is jittered between half and all of its value so that
+// concurrent workers do not retry in lockstep.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	base := t.baseDelay
+	if base <= 0 {
+		base = retryBaseDelay
+	}
+	delay := min(base<<(attempt-1), retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating.
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return true
+		}
+		// Timeouts, resets and refused connections surface as net errors
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
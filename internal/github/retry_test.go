@@ -0,0 +1,89 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransportRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case calls.Add(1) <= 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	var retries atomic.Int32
+	transport := &retryTransport{
+		base:        http.DefaultTransport,
+		maxAttempts: 5,
+		maxElapsed:  time.Minute,
+		baseDelay:   time.Millisecond,
+		onRetry:     func() { retries.Add(1) },
+	}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || retries.Load() != 2 {
+		t.Errorf("status = %d after %d retries, want 200 after 2", resp.StatusCode, retries.Load())
+	}
+
+	retries.Store(0)
+	resp, err = client.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || retries.Load() != 0 {
+		t.Errorf("status = %d after %d retries, want 404 without retries", resp.StatusCode, retries.Load())
+	}
+
+	// Writes are only retried when marked idempotent
+	calls.Store(0)
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || retries.Load() != 0 {
+		t.Errorf("POST status = %d after %d retries, want 502 without retries", resp.StatusCode, retries.Load())
+	}
+}
+
+func TestRetryTransportRetriesDroppedConnections(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &retryTransport{base: http.DefaultTransport, maxAttempts: 3, baseDelay: time.Millisecond}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
@@ -13,26 +13,20 @@ func (c *Client) FetchReviews(ctx context.Context, prNumber int) error {
 		PerPage: 100,
 	}
 
-	for {
-		reviews, resp, err := c.client.PullRequests.ListReviews(ctx, c.owner, c.repo, prNumber, opts)
-		if err != nil {
-			return c.handleError(err, resp.Response)
-		}
-
+	fetch := func(ctx context.Context, page int) ([]*github.PullRequestReview, *github.Response, error) {
+		opts.Page = page
+		return c.client.PullRequests.ListReviews(ctx, c.owner, c.repo, prNumber, opts)
+	}
+	return fetchPages(ctx, c, fetch, func(reviews []*github.PullRequestReview) error {
 		for _, review := range reviews {
 			r := c.convertReview(review, prNumber)
-			if err := c.cache.SaveReview(r); err != nil {
+			if err := c.cache.SaveReview(c.GetRepository(), r); err != nil {
 				return fmt.Errorf("saving review %d: %w", review.GetID(), err)
 			}
+			c.stats.reviews.Add(1)
 		}
-
-		if resp.NextPage == 0 {
-			break
-		}
-		opts.Page = resp.NextPage
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (c *Client) convertReview(review *github.PullRequestReview, prNumber int) *models.Review {
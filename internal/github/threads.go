@@ -0,0 +1,125 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id isResolved isOutdated
+          resolvedBy { __typename login }
+          comments(first: 100) {
+            pageInfo { hasNextPage endCursor }
+            nodes { fullDatabaseId isMinimized minimizedReason }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// threadCommentsQuery pages through the comments of a review thread with
+// more comments than reviewThreadsQuery returns.
+const threadCommentsQuery = `query($id: ID!, $after: String) {
+  node(id: $id) {
+    ... on PullRequestReviewThread {
+      comments(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { fullDatabaseId isMinimized minimizedReason }
+      }
+    }
+  }
+}`
+
+type reviewThreadsData struct {
+	Repository struct {
+		PullRequest struct {
+			ReviewThreads struct {
+				PageInfo graphqlPageInfo       `json:"pageInfo"`
+				Nodes    []graphqlReviewThread `json:"nodes"`
+			} `json:"reviewThreads"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+type threadCommentsData struct {
+	Node struct {
+		Comments graphqlCommentConnection `json:"comments"`
+	} `json:"node"`
+}
+
+// FetchReviewThreads caches whether the review threads of a PR are resolved
+// or outdated and which of their comments were hidden. REST comments do not
+// carry this state, so it comes from GraphQL, which needs a token. PRs
+// without cached code comments have no threads and are skipped.
+func (c *Client) FetchReviewThreads(ctx context.Context, prNumber int) error {
+	if len(c.tokenNames) == 0 {
+		return nil
+	}
+
+	comments, err := c.cache.GetComments(c.GetRepository(), prNumber)
+	if err != nil {
+		return fmt.Errorf("reading cached comments of PR %d: %w", prNumber, err)
+	}
+	if !slices.ContainsFunc(comments, func(comment *models.Comment) bool { return comment.Path != "" }) {
+		return nil
+	}
+
+	variables := map[string]any{
+		"owner":  c.owner,
+		"name":   c.repo,
+		"number": prNumber,
+		"after":  nil,
+	}
+	for {
+		var data reviewThreadsData
+		if err := c.graphql(ctx, reviewThreadsQuery, variables, &data); err != nil {
+			return err
+		}
+		conn := data.Repository.PullRequest.ReviewThreads
+
+		for i := range conn.Nodes {
+			if err := c.fetchThreadComments(ctx, &conn.Nodes[i]); err != nil {
+				return err
+			}
+			thread := conn.Nodes[i].commentThread(prNumber)
+			if thread == nil {
+				continue
+			}
+			if err := c.cache.SaveReviewThread(c.GetRepository(), thread); err != nil {
+				return fmt.Errorf("saving review thread %d: %w", thread.RootID, err)
+			}
+			c.stats.threads.Add(1)
+		}
+
+		if !conn.PageInfo.HasNextPage {
+			return nil
+		}
+		variables["after"] = conn.PageInfo.EndCursor
+	}
+}
+
+// fetchThreadComments adds the comments of thread beyond the first page,
+// so that the state of every comment is known.
+func (c *Client) fetchThreadComments(ctx context.Context, thread *graphqlReviewThread) error {
+	variables := map[string]any{"id": thread.ID}
+	for conn := thread.Comments; conn.PageInfo.HasNextPage; {
+		variables["after"] = conn.PageInfo.EndCursor
+
+		var data threadCommentsData
+		if err := c.graphql(ctx, threadCommentsQuery, variables, &data); err != nil {
+			return err
+		}
+		conn = data.Node.Comments
+		thread.Comments.Nodes = append(thread.Comments.Nodes, conn.Nodes...)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+)
+
+// A thread whose comments do not fit in the first page of the threads query
+const (
+	longThreadFixture = `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+	"pageInfo": {"hasNextPage": false, "endCursor": "t1"},
+	"nodes": [{
+		"id": "PRRT_1", "isResolved": false, "isOutdated": false,
+		"comments": {"pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+			{"fullDatabaseId": "1", "isMinimized": false, "minimizedReason": null}
+		]}
+	}]
+}}}}}`
+	longThreadCommentsFixture = `{"data": {"node": {"comments": {
+	"pageInfo": {"hasNextPage": false, "endCursor": "c2"},
+	"nodes": [{"fullDatabaseId": "2", "isMinimized": true, "minimizedReason": "spam"}]
+}}}}`
+)
+
+func TestFetchReviewThreadsPagesThroughComments(t *testing.T) {
+	var commentQueries []string
+	client, store := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query, _ := io.ReadAll(r.Body)
+		if !bytes.Contains(query, []byte("node(id:")) {
+			_, _ = w.Write([]byte(longThreadFixture))
+			return
+		}
+		commentQueries = append(commentQueries, string(query))
+		_, _ = w.Write([]byte(longThreadCommentsFixture))
+	}))
+
+	comment := &models.Comment{ID: 1, PullNumber: 1, Path: "main.go", Body: "Nit"}
+	if err := store.SaveComment("owner/repo", comment); err != nil {
+		t.Fatalf("SaveComment() error = %v", err)
+	}
+
+	if err := client.FetchReviewThreads(context.Background(), 1); err != nil {
+		t.Fatalf("FetchReviewThreads() error = %v", err)
+	}
+	if len(commentQueries) != 1 || !strings.Contains(commentQueries[0], `"after":"c1"`) ||
+		!strings.Contains(commentQueries[0], `"id":"PRRT_1"`) {
+		t.Errorf("comment queries = %v, want one for thread PRRT_1 after cursor c1", commentQueries)
+	}
+
+	threads, err := store.GetThreadsByPull("owner/repo", []int{1})
+	if err != nil {
+		t.Fatalf("GetThreadsByPull() error = %v", err)
+	}
+	if th := threads[1]; len(th) != 1 || th[0].RootID != 1 || th[0].Minimized[2] != "spam" {
+		t.Errorf("threads = %+v, want thread 1 with its second page comment hidden as spam", th)
+	}
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bonyuta0204/pr-analyzer/pkg/models"
+	"github.com/google/go-github/v50/github"
+)
+
+// timelineEvents lists the timeline events worth caching. Comments, reviews
+// and commits also appear on the timeline but are fetched on their own.
+var timelineEvents = map[string]bool{
+	"review_requested":       true,
+	"review_request_removed": true,
+	"ready_for_review":       true,
+	"convert_to_draft":       true,
+	"labeled":                true,
+	"unlabeled":              true,
+	"assigned":               true,
+	"unassigned":             true,
+	"head_ref_force_pushed":  true,
+	"closed":                 true,
+	"reopened":               true,
+	"merged":                 true,
+}
+
+func (c *Client) FetchTimeline(ctx context.Context, prNumber int) error {
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	fetch := func(ctx context.Context, page int) ([]*github.Timeline, *github.Response, error) {
+		opts.Page = page
+		return c.client.Issues.ListIssueTimeline(ctx, c.owner, c.repo, prNumber, opts)
+	}
+	return fetchPages(ctx, c, fetch, func(events []*github.Timeline) error {
+		for _, event := range events {
+			if !timelineEvents[event.GetEvent()] {
+				continue
+			}
+			e := c.convertTimelineEvent(event, prNumber)
+			if err := c.cache.SaveTimelineEvent(c.GetRepository(), e); err != nil {
+				return fmt.Errorf("saving timeline event %d: %w", event.GetID(), err)
+			}
+			c.stats.timelineEvents.Add(1)
+
+			if e.Event == "merged" {
+				if err := c.recordMergedBy(prNumber, e.Actor); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// recordMergedBy stores the actor of a PR's merged event as the user who
+// merged it, which the REST listing leaves out.
+func (c *Client) recordMergedBy(prNumber int, actor models.User) error {
+	if actor.Login == "" {
+		return nil
+	}
+	if err := c.cache.SetPullMergedBy(c.GetRepository(), prNumber, actor); err != nil {
+		return fmt.Errorf("saving merger of PR %d: %w", prNumber, err)
+	}
+	return nil
+}
+
+func (c *Client) convertTimelineEvent(event *github.Timeline, prNumber int) *models.TimelineEvent {
+	result := &models.TimelineEvent{
+		ID:         event.GetID(),
+		PullNumber: prNumber,
+		Event:      event.GetEvent(),
+		CreatedAt:  event.GetCreatedAt().Time,
+		Label:      event.GetLabel().GetName(),
+		CommitID:   event.GetCommitID(),
+	}
+
+	if event.Actor != nil {
+		result.Actor = models.User{
+			Login: event.Actor.GetLogin(),
+			Type:  event.Actor.GetType(),
+		}
+	}
+
+	if event.Assignee != nil {
+		result.Assignee = &models.User{
+			Login: event.Assignee.GetLogin(),
+			Type:  event.Assignee.GetType(),
+		}
+	}
+
+	if event.Reviewer != nil {
+		result.RequestedReviewer = &models.User{
+			Login: event.Reviewer.GetLogin(),
+			Type:  event.Reviewer.GetType(),
+		}
+	}
+
+	if event.RequestedTeam != nil {
+		result.RequestedTeam = event.RequestedTeam.GetSlug()
+	}
+
+	return result
+}
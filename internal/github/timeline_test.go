@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+const timelineFixture = `[{
+	"id": 1, "event": "assigned", "created_at": "2024-01-01T10:00:00Z",
+	"actor": {"login": "alice", "type": "User"}, "assignee": {"login": "bob", "type": "User"}
+}, {
+	"id": 2, "event": "review_requested", "created_at": "2024-01-01T11:00:00Z",
+	"actor": {"login": "alice", "type": "User"}, "requested_team": {"slug": "core"}
+}, {
+	"event": "committed", "sha": "ccc333", "message": "Address review",
+	"author": {"name": "Alice", "email": "alice@example.com", "date": "2024-01-01T12:00:00Z"}
+}, {
+	"id": 3, "event": "commented", "created_at": "2024-01-01T13:00:00Z",
+	"body": "Rebased", "user": {"login": "alice", "type": "User"}
+}, {
+	"id": 4, "event": "head_ref_force_pushed", "created_at": "2024-01-01T14:00:00Z",
+	"actor": {"login": "alice", "type": "User"}, "commit_id": "ddd444"
+}, {
+	"id": 5, "event": "subscribed", "created_at": "2024-01-01T15:00:00Z",
+	"actor": {"login": "carol", "type": "User"}
+}]`
+
+func TestFetchTimelineKeepsRequestedEvents(t *testing.T) {
+	client, store := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(timelineFixture))
+	}))
+
+	if err := client.FetchTimeline(context.Background(), 1); err != nil {
+		t.Fatalf("FetchTimeline() error = %v", err)
+	}
+	timeline, err := store.GetTimelineByPull("owner/repo", []int{1})
+	if err != nil {
+		t.Fatalf("GetTimelineByPull() error = %v", err)
+	}
+
+	events := timeline[1]
+	if len(events) != 3 {
+		t.Fatalf("cached %d events, want assigned, review_requested and head_ref_force_pushed: %+v", len(events), events)
+	}
+	if e := events[0]; e.Event != "assigned" || e.Actor.Login != "alice" || e.Assignee == nil || e.Assignee.Login != "bob" {
+		t.Errorf("assigned event = %+v, want alice assigning bob", e)
+	}
+	if e := events[1]; e.Event != "review_requested" || e.RequestedTeam != "core" || e.RequestedReviewer != nil {
+		t.Errorf("review_requested event = %+v, want a request for team core", e)
+	}
+	if e := events[2]; e.Event != "head_ref_force_pushed" || e.CommitID != "ddd444" {
+		t.Errorf("head_ref_force_pushed event = %+v, want commit ddd444", e)
+	}
+}
@@ -0,0 +1,196 @@
+package github
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bonyuta0204/pr-analyzer/internal/cache"
+	"github.com/bonyuta0204/pr-analyzer/internal/config"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("writing %s: %v", blockType, err)
+	}
+	return path
+}
+
+// newClientCertificate issues a client certificate signed by a new CA and
+// returns the CA pool along with the certificate and key files.
+func newClientCertificate(t *testing.T) (*x509.CertPool, string, string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("creating CA certificate: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parsing CA certificate: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating client key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "pr-analyzer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("creating client certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("encoding client key: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return pool, writePEM(t, "CERTIFICATE", der), writePEM(t, "EC PRIVATE KEY", keyDER)
+}
+
+// fetchWith fetches owner/repo from apiURL with the given GitHub settings.
+func fetchWith(t *testing.T, apiURL string, github config.GitHubConfig) error {
+	t.Helper()
+
+	store, err := cache.NewStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.Fetch.MaxRetries = 0
+	cfg.GitHub = github
+	cfg.GitHub.Token = "test-token"
+	cfg.GitHub.APIURL = apiURL
+
+	client, err := NewClient(cfg, store, "owner/repo")
+	if err != nil {
+		return err
+	}
+	_, err = client.FetchPullRequests(context.Background(), FetchOptions{Limit: 100})
+	return err
+}
+
+func TestNewClientVerifiesServerWithCustomCA(t *testing.T) {
+	fake := &fakeGitHub{pulls: []map[string]any{newFakePull(1, time.Now().Add(-time.Hour))}}
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+	caFile := writePEM(t, "CERTIFICATE", server.Certificate().Raw)
+
+	if err := fetchWith(t, server.URL, config.GitHubConfig{}); !errors.Is(err, ErrNetwork) {
+		t.Errorf("fetch without the CA error = %v, want ErrNetwork", err)
+	}
+	if err := fetchWith(t, server.URL, config.GitHubConfig{TLS: config.TLSConfig{CAFile: caFile}}); err != nil {
+		t.Errorf("fetch with the CA error = %v", err)
+	}
+	if err := fetchWith(t, server.URL, config.GitHubConfig{TLS: config.TLSConfig{InsecureSkipVerify: true}}); err != nil {
+		t.Errorf("fetch skipping verification error = %v", err)
+	}
+
+	bogus := writePEM(t, "PRIVATE KEY", []byte("not a certificate"))
+	if err := fetchWith(t, server.URL, config.GitHubConfig{TLS: config.TLSConfig{CAFile: bogus}}); err == nil {
+		t.Error("NewClient() accepted a CA bundle without certificates")
+	}
+}
+
+func TestNewClientPresentsClientCertificate(t *testing.T) {
+	clientCAs, certFile, keyFile := newClientCertificate(t)
+
+	fake := &fakeGitHub{pulls: []map[string]any{newFakePull(1, time.Now().Add(-time.Hour))}}
+	server := httptest.NewUnstartedServer(fake)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	caFile := writePEM(t, "CERTIFICATE", server.Certificate().Raw)
+
+	if err := fetchWith(t, server.URL, config.GitHubConfig{TLS: config.TLSConfig{CAFile: caFile}}); !errors.Is(err, ErrNetwork) {
+		t.Errorf("fetch without a client certificate error = %v, want ErrNetwork", err)
+	}
+
+	github := config.GitHubConfig{TLS: config.TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}}
+	if err := fetchWith(t, server.URL, github); err != nil {
+		t.Errorf("fetch with a client certificate error = %v", err)
+	}
+}
+
+func TestNewClientSendsRequestsThroughProxy(t *testing.T) {
+	fake := &fakeGitHub{pulls: []map[string]any{newFakePull(1, time.Now().Add(-time.Hour))}}
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxied request carries the absolute URL of the target
+		if r.URL.Host != "github.invalid" {
+			http.Error(w, "unexpected target "+r.URL.Host, http.StatusBadGateway)
+			return
+		}
+		proxied.Add(1)
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	// github.invalid does not resolve, so only the proxy can answer
+	if err := fetchWith(t, "http://github.invalid", config.GitHubConfig{Proxy: proxy.URL}); err != nil {
+		t.Fatalf("fetch through proxy error = %v", err)
+	}
+	if proxied.Load() == 0 {
+		t.Error("no request went through the proxy")
+	}
+
+	if err := fetchWith(t, "http://github.invalid", config.GitHubConfig{Proxy: "ftp://proxy.example.com"}); err == nil {
+		t.Error("NewClient() accepted an ftp proxy")
+	}
+}
+
+func TestUploadURL(t *testing.T) {
+	tests := []struct {
+		github config.GitHubConfig
+		want   string
+	}{
+		{config.GitHubConfig{APIURL: "https://api.github.com"}, ""},
+		{config.GitHubConfig{APIURL: "https://ghe.example.com/api/v3/"}, "https://ghe.example.com/api/uploads"},
+		{config.GitHubConfig{APIURL: "https://ghe.example.com/api/v3", UploadURL: "https://uploads.example.com/"}, "https://uploads.example.com/"},
+	}
+
+	for _, tt := range tests {
+		if got := uploadURL(tt.github); got != tt.want {
+			t.Errorf("uploadURL(%+v) = %q, want %q", tt.github, got, tt.want)
+		}
+	}
+}
@@ -2,21 +2,29 @@ package ui
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
 )
 
 var (
-	gray  = color.New(color.FgHiBlack)
-	green = color.New(color.FgGreen)
-	white = color.New(color.FgWhite, color.Bold)
-	cyan  = color.New(color.FgCyan)
+	gray   = color.New(color.FgHiBlack)
+	green  = color.New(color.FgGreen)
+	white  = color.New(color.FgWhite, color.Bold)
+	cyan   = color.New(color.FgCyan)
+	yellow = color.New(color.FgYellow)
 )
 
 type ProgressDisplay struct {
 	stopSpinner chan bool
+
+	mu          sync.Mutex
 	isSpinning  bool
+	count       int
+	unit        string
+	pauseUntil  time.Time
+	pauseReason string
 }
 
 func NewProgressDisplay() *ProgressDisplay {
@@ -52,6 +60,24 @@ func (p *ProgressDisplay) ShowProgress(label string, count int, unit string) {
 	p.startSpinner(label, count, unit)
 }
 
+// UpdateProgress changes the count and unit shown by the active spinner.
+func (p *ProgressDisplay) UpdateProgress(count int, unit string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count = count
+	p.unit = unit
+}
+
+// ShowCount prints a completed progress line without a spinner.
+func (p *ProgressDisplay) ShowCount(label string, count int, unit string) {
+	p.stopCurrentSpinner()
+	fmt.Printf("│  ├─ %s %s %s %s\n",
+		gray.Sprint(label+"................"),
+		green.Sprint("✓"),
+		white.Sprintf("%d", count),
+		gray.Sprint(unit))
+}
+
 func (p *ProgressDisplay) StopProgress() {
 	p.stopCurrentSpinner()
 }
@@ -69,15 +95,58 @@ func (p *ProgressDisplay) ShowSuccess(count int, filename, size string) {
 		gray.Sprintf("duckdb -c \"SELECT * FROM '%s'\"", filename))
 }
 
+// ShowDone closes a section that did not export anything.
+func (p *ProgressDisplay) ShowDone(message string) {
+	p.stopCurrentSpinner()
+	fmt.Printf("│\n")
+	fmt.Printf("└─ %s %s\n", green.Sprint("✅"), message)
+}
+
+// ShowRateLimitPause displays a countdown until requests resume. While a
+// spinner is active the countdown replaces its line; otherwise a single
+// notice is printed.
+func (p *ProgressDisplay) ShowRateLimitPause(until time.Time, reason string) {
+	p.mu.Lock()
+	p.pauseUntil = until
+	p.pauseReason = reason
+	spinning := p.isSpinning
+	p.mu.Unlock()
+
+	if !spinning {
+		fmt.Printf("│  %s %s %s\n",
+			yellow.Sprint("⏳"),
+			gray.Sprintf("%s, pausing until", reason),
+			white.Sprint(until.Format("15:04:05")))
+	}
+}
+
+// pauseRemaining returns the time left in the current rate limit pause.
+func (p *ProgressDisplay) pauseRemaining() (time.Duration, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Until(p.pauseUntil), p.pauseReason
+}
+
 func (p *ProgressDisplay) ShowError(err error) {
 	p.stopCurrentSpinner()
 	fmt.Printf("│\n")
 	fmt.Printf("└─ %s Error: %s\n", "❌", err.Error())
 }
 
+// spinnerState returns the count and unit the spinner should display.
+func (p *ProgressDisplay) spinnerState() (int, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.count, p.unit
+}
+
 func (p *ProgressDisplay) startSpinner(label string, count int, unit string) {
 	p.stopSpinner = make(chan bool)
+	p.mu.Lock()
 	p.isSpinning = true
+	p.count = count
+	p.unit = unit
+	p.mu.Unlock()
 
 	go func() {
 		spinners := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
@@ -89,15 +158,24 @@ func (p *ProgressDisplay) startSpinner(label string, count int, unit string) {
 		for {
 			select {
 			case <-p.stopSpinner:
+				count, unit := p.spinnerState()
 				// Clear the line and show final result
-				fmt.Printf("\r│  ├─ %s %s %s %s\n",
+				fmt.Printf("\r\033[K│  ├─ %s %s %s %s\n",
 					gray.Sprint(label+"................"),
 					green.Sprint("✓"),
 					white.Sprintf("%d", count),
 					gray.Sprint(unit))
 				return
 			case <-ticker.C:
-				fmt.Printf("\r│  ├─ %s %s %s %s",
+				if remaining, reason := p.pauseRemaining(); remaining > 0 {
+					fmt.Printf("\r\033[K│  ├─ %s %s %s",
+						yellow.Sprint("⏳"),
+						gray.Sprintf("%s, resuming in", reason),
+						white.Sprint(remaining.Round(time.Second)))
+					continue
+				}
+				count, unit := p.spinnerState()
+				fmt.Printf("\r\033[K│  ├─ %s %s %s %s",
 					gray.Sprint(label+"................"),
 					cyan.Sprint(spinners[i]),
 					white.Sprintf("%d", count),
@@ -109,9 +187,13 @@ func (p *ProgressDisplay) startSpinner(label string, count int, unit string) {
 }
 
 func (p *ProgressDisplay) stopCurrentSpinner() {
-	if p.isSpinning {
+	p.mu.Lock()
+	spinning := p.isSpinning
+	p.isSpinning = false
+	p.mu.Unlock()
+
+	if spinning {
 		p.stopSpinner <- true
-		p.isSpinning = false
 		time.Sleep(50 * time.Millisecond) // Give time for the goroutine to finish
 	}
 }
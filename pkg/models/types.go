@@ -20,18 +20,34 @@ type PullRequest struct {
 	ID                 int64            `json:"id"`
 	Number             int              `json:"number"`
 	Title              string           `json:"title"`
+	Body               string           `json:"body,omitempty"`
 	State              string           `json:"state"`
+	Draft              bool             `json:"draft"`
 	Author             User             `json:"author"`
+	AuthorAssociation  string           `json:"author_association,omitempty"`
 	Assignees          []User           `json:"assignees"`
 	RequestedReviewers []User           `json:"requested_reviewers"`
+	RequestedTeams     []string         `json:"requested_teams,omitempty"`
 	Labels             []Label          `json:"labels"`
+	Milestone          string           `json:"milestone,omitempty"`
 	CreatedAt          time.Time        `json:"created_at"`
 	UpdatedAt          time.Time        `json:"updated_at"`
+	ClosedAt           *time.Time       `json:"closed_at,omitempty"`
 	MergedAt           *time.Time       `json:"merged_at,omitempty"`
+	MergedBy           *User            `json:"merged_by,omitempty"`
+	MergeCommitSHA     string           `json:"merge_commit_sha,omitempty"`
+	BaseRef            string           `json:"base_ref,omitempty"`
+	BaseSHA            string           `json:"base_sha,omitempty"`
+	HeadRef            string           `json:"head_ref,omitempty"`
+	HeadSHA            string           `json:"head_sha,omitempty"`
 	Stats              PullRequestStats `json:"stats"`
 	Files              []File           `json:"files,omitempty"`
 	Reviews            []Review         `json:"reviews,omitempty"`
 	Comments           []Comment        `json:"comments,omitempty"`
+	Threads            []CommentThread  `json:"threads,omitempty"`
+	Commits            []Commit         `json:"commits,omitempty"`
+	Checks             []CheckRun       `json:"checks,omitempty"`
+	Timeline           []TimelineEvent  `json:"timeline,omitempty"`
 	RawJSON            json.RawMessage  `json:"-"`
 }
 
@@ -42,6 +58,7 @@ type PullRequestStats struct {
 	Comments       int `json:"comments"`
 	ReviewComments int `json:"review_comments"`
 	Reviews        int `json:"reviews"`
+	Commits        int `json:"commits"`
 }
 
 type File struct {
@@ -88,9 +105,20 @@ type CodeContext struct {
 	AfterLines  []string `json:"after_lines,omitempty"`
 }
 
+// CommentThread is a review thread: a code comment, the replies to it and
+// the state of the conversation. Replies point at the root comment through
+// InReplyToID.
 type CommentThread struct {
-	RootID  int64     `json:"root_id"`
-	Replies []Comment `json:"replies,omitempty"`
+	RootID     int64 `json:"root_id"`
+	PullNumber int   `json:"-"`
+	IsResolved bool  `json:"is_resolved"`
+	ResolvedBy *User `json:"resolved_by,omitempty"`
+	// IsOutdated is set once the lines the thread comments on have changed
+	IsOutdated bool `json:"is_outdated"`
+	// Minimized maps the hidden comments of the thread to the reason they
+	// were hidden, such as outdated, resolved or spam
+	Minimized map[int64]string `json:"minimized,omitempty"`
+	Replies   []Comment        `json:"replies,omitempty"`
 }
 
 type ExportComment struct {
@@ -116,4 +144,86 @@ type SyncMetadata struct {
 	TotalPRs     int       `json:"total_prs"`
 	OpenPRs      int       `json:"open_prs"`
 	ClosedPRs    int       `json:"closed_prs"`
+	Complete     bool      `json:"complete"`
+}
+
+// FetchCheckpoint records the progress of an interrupted sync.
+type FetchCheckpoint struct {
+	Repo            string     `json:"repo"`
+	Page            int        `json:"page"`
+	Cursor          string     `json:"cursor,omitempty"`
+	Listed          int        `json:"listed"`
+	LastCompletedPR int        `json:"last_completed_pr"`
+	Pending         []int      `json:"pending"`
+	Limit           int        `json:"limit"`
+	Refetch         bool       `json:"refetch"`
+	Cutoff          *time.Time `json:"cutoff,omitempty"`
+	SyncCutoff      bool       `json:"sync_cutoff"`
+	Unbounded       bool       `json:"unbounded"`
+	StartedAt       time.Time  `json:"started_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TimelineEvent is an event from a PR's issue timeline, such as a review
+// request, a label change or a force push. Fields other than Event, Actor
+// and CreatedAt are only set for the events they describe.
+type TimelineEvent struct {
+	ID                int64           `json:"id"`
+	PullNumber        int             `json:"-"`
+	Event             string          `json:"event"`
+	Actor             User            `json:"actor"`
+	CreatedAt         time.Time       `json:"created_at"`
+	Label             string          `json:"label,omitempty"`
+	Assignee          *User           `json:"assignee,omitempty"`
+	RequestedReviewer *User           `json:"requested_reviewer,omitempty"`
+	RequestedTeam     string          `json:"requested_team,omitempty"`
+	CommitID          string          `json:"commit_id,omitempty"`
+	RawJSON           json.RawMessage `json:"-"`
+}
+
+// CommitIdentity is the author or committer recorded in a commit. Login is
+// the GitHub account the email belongs to, if any.
+type CommitIdentity struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Login string `json:"login,omitempty"`
+}
+
+// Commit is a commit of a PR, in the order GitHub lists them. CoAuthors
+// are parsed from the Co-authored-by trailers of the message.
+type Commit struct {
+	SHA                string           `json:"sha"`
+	PullNumber         int              `json:"-"`
+	Position           int              `json:"-"`
+	Message            string           `json:"message"`
+	Author             CommitIdentity   `json:"author"`
+	Committer          CommitIdentity   `json:"committer"`
+	CoAuthors          []CommitIdentity `json:"co_authors,omitempty"`
+	AuthoredAt         time.Time        `json:"authored_at"`
+	CommittedAt        time.Time        `json:"committed_at"`
+	Verified           bool             `json:"verified"`
+	VerificationReason string           `json:"verification_reason,omitempty"`
+	RawJSON            json.RawMessage  `json:"-"`
+}
+
+// CheckRun is a CI result for the head commit of a PR: a check run, or a
+// commit status from an integration that does not use checks. Each re-run
+// of a check is a separate CheckRun.
+type CheckRun struct {
+	ID         int64 `json:"id"`
+	PullNumber int   `json:"-"`
+	// Kind is check_run or status
+	Kind    string `json:"kind"`
+	HeadSHA string `json:"head_sha"`
+	// Name is the check name, or the context of a status
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Conclusion is empty until the check completes
+	Conclusion string `json:"conclusion,omitempty"`
+	// App is the slug of the app that ran the check, or the login of the
+	// user who reported the status
+	App         string          `json:"app,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	RawJSON     json.RawMessage `json:"-"`
 }